@@ -0,0 +1,398 @@
+package httpx
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Throughput 连接的传输统计，字节数为线路上的实际字节数（包括 TLS 握手、加密开销以及代理协商）
+type Throughput struct {
+	BytesSent     int64         // 发送的字节数
+	BytesReceived int64         // 接收的字节数
+	Duration      time.Duration // 从首次传输到最近一次传输的时长
+}
+
+// UpRate 平均上传速率（字节/秒）
+func (t Throughput) UpRate() float64 {
+	return rate(t.BytesSent, t.Duration)
+}
+
+// DownRate 平均下载速率（字节/秒）
+func (t Throughput) DownRate() float64 {
+	return rate(t.BytesReceived, t.Duration)
+}
+
+func (t Throughput) String() string {
+	return fmt.Sprintf("sent=%s (%s/s) received=%s (%s/s) duration=%s",
+		formatBytes(float64(t.BytesSent)), formatBytes(t.UpRate()),
+		formatBytes(float64(t.BytesReceived)), formatBytes(t.DownRate()), t.Duration.Round(time.Millisecond))
+}
+
+func rate(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// formatBytes 将字节数格式化为便于阅读的形式
+func formatBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", n, units[i])
+}
+
+const (
+	BandwidthMaxHosts  = 1000    // 带宽统计区分的主机数上限
+	BandwidthOtherHost = "other" // 超过主机数上限后合并统计使用的主机名
+)
+
+// BandwidthStats 带宽统计，按主机（不包括端口）区分，超过 BandwidthMaxHosts 后新主机合并到 BandwidthOtherHost
+type BandwidthStats struct {
+	Total Throughput
+	Hosts map[string]Throughput
+}
+
+// rateLimiter 令牌桶限速器，多个连接共享时按请求顺序排队
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒的字节数，小于等于 0 时不限制
+	burst  float64 // 令牌桶容量，同时也是单次读写的最大字节数
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	l := &rateLimiter{}
+	l.setRate(bytesPerSec)
+	return l
+}
+
+// setRate 修改速率，已建立的连接同样生效
+func (l *rateLimiter) setRate(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(bytesPerSec)
+	// 每次最多传输 100ms 的数据，使速率更加平滑
+	l.burst = min(max(l.rate/10, 512), 64*1024)
+	l.tokens = l.burst
+	l.last = time.Now()
+}
+
+// chunk 单次读写的最大字节数，不限制时返回 0
+func (l *rateLimiter) chunk() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0
+	}
+	return int(l.burst)
+}
+
+// reserve 消耗 n 个令牌，返回令牌不足时需要等待的时长
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 || n <= 0 {
+		return 0
+	}
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel 归还 reserve 消耗的令牌
+func (l *rateLimiter) cancel(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate > 0 && n > 0 {
+		l.tokens = min(l.burst, l.tokens+float64(n))
+	}
+}
+
+// throughput 传输统计
+type throughput struct {
+	mu          sync.Mutex
+	sent        int64
+	received    int64
+	first, last time.Time
+}
+
+func (t *throughput) add(sent, received int) {
+	if sent <= 0 && received <= 0 {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.first.IsZero() {
+		t.first = now
+	}
+	t.last = now
+	t.sent += int64(sent)
+	t.received += int64(received)
+}
+
+func (t *throughput) snapshot() Throughput {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Throughput{BytesSent: t.sent, BytesReceived: t.received, Duration: t.last.Sub(t.first)}
+}
+
+// hostBandwidth 主机的限速器
+type hostBandwidth struct {
+	up, down *rateLimiter
+}
+
+// bandwidthLimiter 全局以及按主机的带宽限制与统计
+type bandwidthLimiter struct {
+	up, down *rateLimiter
+	total    throughput
+
+	mu    sync.Mutex
+	hosts map[string]*hostBandwidth // 按 host 或 host:port 区分的限速器
+	stats map[string]*throughput
+}
+
+func newBandwidthLimiter() *bandwidthLimiter {
+	return &bandwidthLimiter{
+		up:    newRateLimiter(0),
+		down:  newRateLimiter(0),
+		hosts: make(map[string]*hostBandwidth),
+		stats: make(map[string]*throughput),
+	}
+}
+
+// setHostLimit 设置主机的带宽限制，均为 0 时删除
+func (b *bandwidthLimiter) setHostLimit(host string, up, down int64) {
+	host = strings.ToLower(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if up == 0 && down == 0 {
+		if hb, ok := b.hosts[host]; ok {
+			// 已建立的连接可能仍在使用该限速器
+			hb.up.setRate(0)
+			hb.down.setRate(0)
+			delete(b.hosts, host)
+		}
+		return
+	}
+	if hb, ok := b.hosts[host]; ok {
+		hb.up.setRate(up)
+		hb.down.setRate(down)
+		return
+	}
+	b.hosts[host] = &hostBandwidth{up: newRateLimiter(up), down: newRateLimiter(down)}
+}
+
+// wrap 包装连接，addr 为原始请求的 host:port（使用连接路由时不是实际连接的地址），优先匹配 host:port，其次匹配 host
+func (b *bandwidthLimiter) wrap(addr string, conn net.Conn) net.Conn {
+	addr = strings.ToLower(addr)
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := &throttledConn{
+		Conn:    conn,
+		up:      []*rateLimiter{b.up},
+		down:    []*rateLimiter{b.down},
+		total:   &b.total,
+		changed: make(chan struct{}),
+	}
+	hb, ok := b.hosts[addr]
+	if !ok {
+		hb, ok = b.hosts[host]
+	}
+	if ok {
+		c.up = append(c.up, hb.up)
+		c.down = append(c.down, hb.down)
+	}
+	if c.host = b.stats[host]; c.host == nil {
+		if len(b.stats) >= BandwidthMaxHosts {
+			host = BandwidthOtherHost
+		}
+		if c.host = b.stats[host]; c.host == nil {
+			c.host = &throughput{}
+			b.stats[host] = c.host
+		}
+	}
+	return c
+}
+
+// snapshot 获取带宽统计
+func (b *bandwidthLimiter) snapshot() *BandwidthStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := &BandwidthStats{Total: b.total.snapshot(), Hosts: make(map[string]Throughput, len(b.stats))}
+	for host, t := range b.stats {
+		stats.Hosts[host] = t.snapshot()
+	}
+	return stats
+}
+
+// throttledConn 限速并统计流量的连接，限速等待不会超过读写的截止时间
+type throttledConn struct {
+	net.Conn
+	up, down    []*rateLimiter
+	total, host *throughput
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	closed        bool
+	changed       chan struct{} // 截止时间被修改或连接关闭时关闭并重新创建
+}
+
+// chunkSize 获取单次读写的最大字节数，不限制时返回 n
+func chunkSize(limiters []*rateLimiter, n int) int {
+	for _, l := range limiters {
+		if c := l.chunk(); c > 0 && c < n {
+			n = c
+		}
+	}
+	return n
+}
+
+// reserve 从所有限速器中消耗 n 个令牌，返回需要等待的时长
+func reserve(limiters []*rateLimiter, n int) time.Duration {
+	var delay time.Duration
+	for _, l := range limiters {
+		delay = max(delay, l.reserve(n))
+	}
+	return delay
+}
+
+func (c *throttledConn) Read(p []byte) (int, error) {
+	if size := chunkSize(c.down, len(p)); size > 0 {
+		p = p[:size]
+	}
+	n, err := c.Conn.Read(p)
+	c.total.add(0, n)
+	c.host.add(0, n)
+	// 数据已经读取，等待被截止时间打断时仍然返回，下一次读取时由底层连接返回超时错误
+	_ = c.sleep(reserve(c.down, n), true)
+	return n, err
+}
+
+func (c *throttledConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		size := chunkSize(c.up, len(p)-written)
+		if err := c.sleep(reserve(c.up, size), false); err != nil {
+			for _, l := range c.up {
+				l.cancel(size)
+			}
+			return written, err
+		}
+		n, err := c.Conn.Write(p[written : written+size])
+		written += n
+		c.total.add(n, 0)
+		c.host.add(n, 0)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// sleep 限速等待，到达读（写）的截止时间或连接关闭时提前返回错误，等待期间修改截止时间同样生效
+func (c *throttledConn) sleep(delay time.Duration, read bool) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		deadline, changed, closed := c.writeDeadline, c.changed, c.closed
+		if read {
+			deadline = c.readDeadline
+		}
+		c.mu.Unlock()
+		if closed {
+			return net.ErrClosed
+		}
+
+		var expired <-chan time.Time
+		var deadlineTimer *time.Timer
+		if !deadline.IsZero() {
+			remain := time.Until(deadline)
+			if remain <= 0 {
+				return os.ErrDeadlineExceeded
+			}
+			deadlineTimer = time.NewTimer(remain)
+			expired = deadlineTimer.C
+		}
+		var err error
+		done := true
+		select {
+		case <-timer.C:
+		case <-expired:
+			err = os.ErrDeadlineExceeded
+		case <-changed:
+			done = false
+		}
+		if deadlineTimer != nil {
+			deadlineTimer.Stop()
+		}
+		if done {
+			return err
+		}
+	}
+}
+
+// update 修改截止时间或关闭状态，并唤醒正在等待的读写
+func (c *throttledConn) update(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *throttledConn) SetDeadline(t time.Time) error {
+	c.update(func() {
+		c.readDeadline, c.writeDeadline = t, t
+	})
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *throttledConn) SetReadDeadline(t time.Time) error {
+	c.update(func() {
+		c.readDeadline = t
+	})
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *throttledConn) SetWriteDeadline(t time.Time) error {
+	c.update(func() {
+		c.writeDeadline = t
+	})
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *throttledConn) Close() error {
+	c.update(func() {
+		c.closed = true
+	})
+	return c.Conn.Close()
+}
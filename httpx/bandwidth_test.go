@@ -0,0 +1,174 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientBandwidthLimit(t *testing.T) {
+	body := strings.Repeat("a", 32*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	if stats := client.BandwidthStats(); stats.Total.BytesReceived != 0 || len(stats.Hosts) != 0 {
+		t.Fatalf("unexpected stats before limit: %+v", stats)
+	}
+
+	// 下载 32KB，限速 64KB/s，扣除初始的令牌后至少需要约 0.4 秒
+	client.SetBandwidthLimit(0, 64*1024)
+	start := time.Now()
+	resp, err := client.R().Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond || len(resp.Body()) != len(body) {
+		t.Fatalf("download not throttled: %s, %d bytes", elapsed, len(resp.Body()))
+	}
+
+	stats := client.BandwidthStats()
+	host := stats.Hosts["127.0.0.1"]
+	if stats.Total.BytesReceived < int64(len(body)) || host.BytesReceived != stats.Total.BytesReceived || host.BytesSent == 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if rate := stats.Total.DownRate(); rate <= 0 || rate > 96*1024 {
+		t.Fatalf("unexpected download rate %.0f", rate)
+	}
+
+	// 主机限制与全局限制同时生效，上传 16KB，限速 32KB/s
+	client.SetBandwidthLimit(0, 0).SetHostBandwidthLimit(server.Listener.Addr().String(), 32*1024, 0)
+	client.fastClient.CloseIdleConnections()
+	start = time.Now()
+	if _, err = client.R().SetBodyString(strings.Repeat("b", 16*1024)).Post(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Fatalf("upload not throttled: %s", elapsed)
+	}
+	if sent := client.BandwidthStats().Hosts["127.0.0.1"].BytesSent; sent < 16*1024 {
+		t.Fatalf("unexpected sent bytes %d", sent)
+	}
+
+	// 取消主机限制后不再限速
+	client.SetHostBandwidthLimit(server.Listener.Addr().String(), 0, 0)
+	start = time.Now()
+	if _, err = client.R().SetBodyString(strings.Repeat("b", 16*1024)).Post(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("upload should not be throttled: %s", elapsed)
+	}
+}
+
+func TestBandwidthRoutedHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// 使用连接路由时按 URL 中的主机匹配限制
+	client := NewClient().SetHostBandwidthLimit("api.example.com", 32*1024, 0)
+	start := time.Now()
+	_, err := client.R().SetConnectAddress(u.Host).SetBodyString(strings.Repeat("b", 16*1024)).
+		Post("http://api.example.com:" + u.Port() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Fatalf("upload not throttled: %s", elapsed)
+	}
+	if sent := client.BandwidthStats().Hosts["api.example.com"].BytesSent; sent < 16*1024 {
+		t.Fatalf("unexpected sent bytes %d", sent)
+	}
+}
+
+func TestThrottledConnDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_, _ = io.Copy(io.Discard, server)
+	}()
+
+	b := newBandwidthLimiter()
+	b.up.setRate(1024)
+	conn := b.wrap("example.com:80", client)
+	defer conn.Close()
+
+	// 写入 8KB 需要约 7 秒，截止时间到达后立即返回
+	_ = conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+	start := time.Now()
+	_, err := conn.Write(make([]byte, 8*1024))
+	if !errors.Is(err, os.ErrDeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("expected deadline exceeded, got %v after %s", err, time.Since(start))
+	}
+
+	// 等待期间修改截止时间
+	_ = conn.SetWriteDeadline(time.Time{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = conn.SetWriteDeadline(time.Now())
+	}()
+	start = time.Now()
+	if _, err = conn.Write(make([]byte, 8*1024)); !errors.Is(err, os.ErrDeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("expected deadline exceeded, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(10 * 1024)
+	if chunk := l.chunk(); chunk != 1024 {
+		t.Fatalf("unexpected chunk %d", chunk)
+	}
+	start := time.Now()
+	// 初始令牌为 1KB，之后每 1KB 需要 100ms
+	for i := 0; i < 4; i++ {
+		time.Sleep(l.reserve(1024))
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("unexpected elapsed %s", elapsed)
+	}
+
+	l.setRate(0)
+	if l.reserve(1<<20) != 0 || l.chunk() != 0 {
+		t.Fatal("limiter should be disabled")
+	}
+}
+
+func TestThroughputString(t *testing.T) {
+	tp := Throughput{BytesSent: 2048, BytesReceived: 3 << 20, Duration: 2 * time.Second}
+	if s := tp.String(); s != "sent=2.0KB (1.0KB/s) received=3.0MB (1.5MB/s) duration=2s" {
+		t.Fatalf("unexpected string %q", s)
+	}
+}
+
+func TestBandwidthMaxHosts(t *testing.T) {
+	b := newBandwidthLimiter()
+	for i := 0; i <= BandwidthMaxHosts; i++ {
+		client, server := net.Pipe()
+		conn := b.wrap(fmt.Sprintf("host-%d:80", i), client)
+		go func() {
+			_, _ = io.Copy(io.Discard, server)
+		}()
+		_, _ = conn.Write([]byte("x"))
+		_ = conn.Close()
+		_ = server.Close()
+	}
+	stats := b.snapshot()
+	if len(stats.Hosts) != BandwidthMaxHosts+1 || stats.Hosts[BandwidthOtherHost].BytesSent != 1 ||
+		stats.Hosts["host-0"].BytesSent != 1 {
+		t.Fatalf("unexpected hosts: %d, other %v", len(stats.Hosts), stats.Hosts[BandwidthOtherHost])
+	}
+}
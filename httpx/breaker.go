@@ -0,0 +1,259 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭，请求正常发送
+	BreakerOpen                         // 打开，请求直接失败
+	BreakerHalfOpen                     // 半开，冷却结束后允许少量探测请求
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// ErrCircuitOpen 熔断器打开时请求直接失败，可通过 errors.Is 判断
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError 熔断器打开时返回的错误
+type CircuitOpenError struct {
+	Host       string        // 被熔断的主机
+	RetryAfter time.Duration // 距离进入半开状态的剩余时间
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %s, retry after %s", e.Host, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerConfig 熔断器配置，ConsecutiveFailures 与 ErrorRate 任意一个达到阈值时打开熔断器。
+// 处于关闭状态且超过 Window 没有请求的主机会被移除（连续失败次数随之清零），避免扫描大量主机时占用的内存持续增长
+type BreakerConfig struct {
+	ConsecutiveFailures int                                           // 连续失败次数阈值，为 0 时不启用
+	ErrorRate           float64                                       // 错误率阈值（0-1），为 0 时不启用
+	MinRequests         int                                           // 统计错误率所需的最少请求数，默认为 10
+	Window              time.Duration                                 // 错误率统计窗口，默认为 1 分钟
+	Cooldown            time.Duration                                 // 打开后进入半开状态的冷却时间，默认为 30 秒
+	HalfOpenRequests    int                                           // 半开状态允许同时进行的探测请求数，默认为 1
+	IsFailure           func(resp *fasthttp.Response, err error) bool // 判断请求是否失败，默认仅请求出错时视为失败
+	OnStateChange       func(host string, from, to BreakerState)      // 状态变化回调
+}
+
+// circuitBreaker 按主机区分的熔断器
+type circuitBreaker struct {
+	config  BreakerConfig
+	hosts   map[string]*hostBreaker
+	sweptAt time.Time // 上次清理空闲主机的时间
+	mu      sync.Mutex
+}
+
+// hostBreaker 单个主机的熔断状态
+type hostBreaker struct {
+	state       BreakerState
+	failures    int       // 连续失败次数
+	requests    int       // 统计窗口内的请求数
+	errors      int       // 统计窗口内的失败数
+	windowStart time.Time // 统计窗口开始时间
+	openedAt    time.Time // 打开的时间
+	probes      int       // 半开状态进行中的探测请求数
+	lastSeen    time.Time // 最近一次请求的时间
+}
+
+func newCircuitBreaker(config BreakerConfig) *circuitBreaker {
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(_ *fasthttp.Response, err error) bool {
+			return err != nil
+		}
+	}
+	return &circuitBreaker{config: config, hosts: make(map[string]*hostBreaker), sweptAt: time.Now()}
+}
+
+// allow 判断是否允许向主机发送请求
+func (b *circuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	h := b.host(host)
+	from := h.state
+
+	if h.state == BreakerOpen {
+		if wait := b.config.Cooldown - time.Since(h.openedAt); wait > 0 {
+			b.mu.Unlock()
+			return &CircuitOpenError{Host: host, RetryAfter: wait}
+		}
+		h.state = BreakerHalfOpen
+		h.probes = 0
+	}
+	if h.state == BreakerHalfOpen {
+		if h.probes >= b.config.HalfOpenRequests {
+			b.mu.Unlock()
+			b.notify(host, from, h.state)
+			return &CircuitOpenError{Host: host}
+		}
+		h.probes++
+	}
+	to := h.state
+	b.mu.Unlock()
+
+	b.notify(host, from, to)
+	return nil
+}
+
+// done 记录请求结果并更新熔断状态
+func (b *circuitBreaker) done(host string, resp *fasthttp.Response, err error) {
+	failed := b.config.IsFailure(resp, err)
+
+	b.mu.Lock()
+	h := b.host(host)
+	from := h.state
+
+	now := time.Now()
+	if now.Sub(h.windowStart) > b.config.Window {
+		h.windowStart = now
+		h.requests = 0
+		h.errors = 0
+	}
+	h.requests++
+
+	switch {
+	case h.state == BreakerOpen:
+		// 熔断器打开前已发出的请求，不再影响状态
+	case h.state == BreakerHalfOpen:
+		h.probes--
+		if failed {
+			h.open(now)
+		} else {
+			h.reset(now)
+		}
+	case !failed:
+		h.failures = 0
+	default:
+		h.failures++
+		h.errors++
+		if b.config.ConsecutiveFailures > 0 && h.failures >= b.config.ConsecutiveFailures ||
+			b.config.ErrorRate > 0 && h.requests >= b.config.MinRequests &&
+				float64(h.errors)/float64(h.requests) >= b.config.ErrorRate {
+			h.open(now)
+		}
+	}
+	to := h.state
+	b.mu.Unlock()
+
+	b.notify(host, from, to)
+}
+
+// state 获取主机当前的熔断状态，冷却结束的打开状态视为半开
+func (b *circuitBreaker) state(host string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.hosts[host]
+	if !ok {
+		return BreakerClosed
+	}
+	if h.state == BreakerOpen && time.Since(h.openedAt) >= b.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return h.state
+}
+
+// host 获取主机的熔断状态，不存在时创建，需持有锁
+func (b *circuitBreaker) host(host string) *hostBreaker {
+	now := time.Now()
+	if now.Sub(b.sweptAt) >= b.config.Window {
+		b.sweep(now)
+	}
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostBreaker{windowStart: now}
+		b.hosts[host] = h
+	}
+	h.lastSeen = now
+	return h
+}
+
+// sweep 移除处于关闭状态且超过统计窗口没有请求的主机，需持有锁
+func (b *circuitBreaker) sweep(now time.Time) {
+	b.sweptAt = now
+	for host, h := range b.hosts {
+		if h.state == BreakerClosed && now.Sub(h.lastSeen) >= b.config.Window {
+			delete(b.hosts, host)
+		}
+	}
+}
+
+func (b *circuitBreaker) notify(host string, from, to BreakerState) {
+	if from != to && b.config.OnStateChange != nil {
+		b.config.OnStateChange(host, from, to)
+	}
+}
+
+func (h *hostBreaker) open(now time.Time) {
+	h.state = BreakerOpen
+	h.openedAt = now
+	h.failures = 0
+	h.probes = 0
+}
+
+func (h *hostBreaker) reset(now time.Time) {
+	h.state = BreakerClosed
+	h.failures = 0
+	h.requests = 0
+	h.errors = 0
+	h.windowStart = now
+	h.probes = 0
+}
+
+// breakerTransport 经过熔断器发送请求的传输层
+type breakerTransport struct {
+	Transport
+	breaker *circuitBreaker
+}
+
+func (t breakerTransport) RoundTrip(cli *Client, req *fasthttp.Request, resp *fasthttp.Response) (*Timing, error) {
+	host := breakerHost(req)
+	if err := t.breaker.allow(host); err != nil {
+		return nil, err
+	}
+	timing, err := t.Transport.RoundTrip(cli, req, resp)
+	t.breaker.done(host, resp, err)
+	return timing, err
+}
+
+// breakerHost 获取熔断器使用的主机，设置了连接地址时使用实际连接的地址
+func breakerHost(req *fasthttp.Request) string {
+	host := string(req.URI().Host())
+	if _, _, connect, routed := parseRouteAddr(host); routed {
+		return connect
+	}
+	return host
+}
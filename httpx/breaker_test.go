@@ -0,0 +1,174 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var failing atomic.Bool
+	var dials atomic.Int32
+	var mu sync.Mutex
+	var changes []string
+
+	client := NewClient().SetDial(func(addr string) (net.Conn, error) {
+		dials.Add(1)
+		if failing.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return net.Dial("tcp", server.Listener.Addr().String())
+	}).SetCircuitBreaker(&BreakerConfig{
+		ConsecutiveFailures: 2,
+		Cooldown:            50 * time.Millisecond,
+		OnStateChange: func(host string, from, to BreakerState) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, fmt.Sprintf("%s %s->%s", host, from, to))
+		},
+	})
+
+	failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := client.R().Get("http://target.local/"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected dial error, got %v", err)
+		}
+	}
+	if state := client.BreakerState("target.local"); state != BreakerOpen {
+		t.Fatalf("expected open, got %s", state)
+	}
+
+	// 熔断器打开时直接失败，不再建立连接
+	before := dials.Load()
+	_, err := client.R().Get("http://target.local/")
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Host != "target.local" {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if dials.Load() != before {
+		t.Fatal("request should fail fast without dialing")
+	}
+	// 其他主机不受影响
+	if client.BreakerState("other.local") != BreakerClosed {
+		t.Fatal("other host should be closed")
+	}
+
+	// 冷却结束后半开，探测失败重新打开
+	time.Sleep(60 * time.Millisecond)
+	if _, err = client.R().Get("http://target.local/"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected probe dial error, got %v", err)
+	}
+	if state := client.BreakerState("target.local"); state != BreakerOpen {
+		t.Fatalf("expected open after failed probe, got %s", state)
+	}
+
+	// 探测成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	if _, err = client.R().Get("http://target.local/"); err != nil {
+		t.Fatal(err)
+	}
+	if state := client.BreakerState("target.local"); state != BreakerClosed {
+		t.Fatalf("expected closed, got %s", state)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{
+		"target.local closed->open",
+		"target.local open->half-open",
+		"target.local half-open->open",
+		"target.local open->half-open",
+		"target.local half-open->closed",
+	}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected state changes: %v", changes)
+	}
+}
+
+func TestCircuitBreakerErrorRate(t *testing.T) {
+	b := newCircuitBreaker(BreakerConfig{ErrorRate: 0.5, MinRequests: 4})
+	for _, failed := range []bool{false, true, false, true} {
+		if err := b.allow("h"); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if failed {
+			err = errors.New("failed")
+		}
+		b.done("h", nil, err)
+	}
+	if b.state("h") != BreakerOpen {
+		t.Fatal("expected open when error rate reached")
+	}
+}
+
+func TestCircuitBreakerCache(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	client := NewClient().SetCache(NewMemoryCacheStore(100)).SetCircuitBreaker(&BreakerConfig{
+		ConsecutiveFailures: 1,
+		IsFailure: func(resp *fasthttp.Response, err error) bool {
+			return err != nil || resp.StatusCode() >= 500
+		},
+	})
+	if _, err := client.R().Get(server.URL + "/cached"); err != nil {
+		t.Fatal(err)
+	}
+	failing.Store(true)
+	if _, err := client.R().Get(server.URL + "/failing"); err != nil {
+		t.Fatal(err)
+	}
+	if state := client.BreakerState(server.Listener.Addr().String()); state != BreakerOpen {
+		t.Fatalf("expected open, got %s", state)
+	}
+
+	// 熔断器打开时仍然使用新鲜的缓存
+	resp, err := client.R().Get(server.URL + "/cached")
+	if err != nil || resp.CacheStatus() != CacheHit || string(resp.Body()) != "/cached" {
+		t.Fatalf("expected cache hit while open, got %v, %v", resp, err)
+	}
+	if _, err = client.R().Get(server.URL + "/failing"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+}
+
+func TestCircuitBreakerSweep(t *testing.T) {
+	b := newCircuitBreaker(BreakerConfig{ConsecutiveFailures: 1, Window: 20 * time.Millisecond, Cooldown: time.Minute})
+	for i := 0; i < 100; i++ {
+		host := fmt.Sprintf("host-%d", i)
+		_ = b.allow(host)
+		b.done(host, nil, nil)
+	}
+	_ = b.allow("down")
+	b.done("down", nil, errors.New("failed"))
+
+	// 超过统计窗口后，空闲且处于关闭状态的主机被移除，打开状态的主机保留
+	time.Sleep(30 * time.Millisecond)
+	_ = b.allow("new")
+	b.mu.Lock()
+	n := len(b.hosts)
+	b.mu.Unlock()
+	if n != 2 || b.state("down") != BreakerOpen {
+		t.Fatalf("expected idle hosts to be evicted, %d hosts left, down is %s", n, b.state("down"))
+	}
+}
@@ -0,0 +1,313 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/valyala/fasthttp"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheStatus 响应的缓存状态
+type CacheStatus string
+
+const (
+	CacheNone        CacheStatus = ""            // 未启用缓存、跳过缓存或请求不可缓存
+	CacheMiss        CacheStatus = "MISS"        // 未命中缓存，响应来自服务器
+	CacheHit         CacheStatus = "HIT"         // 命中缓存，响应直接来自缓存
+	CacheRevalidated CacheStatus = "REVALIDATED" // 缓存已过期，经服务器验证（304）后使用缓存
+)
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	Response     []byte            `json:"response"`       // 完整的响应报文
+	RequestTime  time.Time         `json:"request_time"`   // 发起请求的时间
+	ResponseTime time.Time         `json:"response_time"`  // 收到响应的时间
+	Vary         map[string]string `json:"vary,omitempty"` // Vary 指定的请求头及其取值
+}
+
+// responseCache 遵循 RFC 9111 的私有缓存，缓存 GET 请求的响应，支持 Cache-Control、Expires 以及
+// ETag/Last-Modified 条件请求验证
+type responseCache struct {
+	store CacheStore
+}
+
+// heuristicStatusCodes 允许启发式缓存的状态码
+var heuristicStatusCodes = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+func (c *responseCache) roundTrip(cli *Client, transport Transport, req *fasthttp.Request, resp *fasthttp.Response) (*exchange, error) {
+	key := string(req.URI().FullURI())
+	method := string(req.Header.Method())
+	reqCC := parseCacheControl(peekHeader(req.Header.All(), "Cache-Control"))
+
+	if method != MethodGet || reqCC.has("no-store") {
+		timing, err := transport.RoundTrip(cli, req, resp)
+		if err != nil {
+			return nil, err
+		}
+		// 不安全的请求方法执行成功后，对应地址的缓存失效
+		if method != MethodHead && method != MethodGet && method != MethodOptions && method != MethodTrace &&
+			resp.StatusCode() < 400 {
+			c.store.Delete(key)
+		}
+		return &exchange{timing: timing}, nil
+	}
+
+	start := time.Now()
+	entry := c.load(key, req)
+	var stored *fasthttp.Response
+	if entry != nil {
+		stored = fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(stored)
+		if err := entry.restore(stored); err != nil {
+			entry = nil
+		}
+	}
+
+	if entry != nil && !reqCC.has("no-cache") && entry.fresh(stored, reqCC, start) {
+		stored.CopyTo(resp)
+		resp.Header.Set("Age", strconv.Itoa(int(entry.age(stored, start).Seconds())))
+		return &exchange{timing: &Timing{Total: time.Since(start)}, cacheStatus: CacheHit}, nil
+	}
+
+	if reqCC.has("only-if-cached") {
+		resp.Reset()
+		resp.SetStatusCode(fasthttp.StatusGatewayTimeout)
+		return &exchange{timing: &Timing{Total: time.Since(start)}, cacheStatus: CacheMiss}, nil
+	}
+
+	// 缓存已过期，使用条件请求向服务器验证
+	var conditional []string
+	if entry != nil {
+		if etag := stored.Header.Peek("ETag"); len(etag) > 0 && len(peekHeader(req.Header.All(), "If-None-Match")) == 0 {
+			req.Header.SetBytesV("If-None-Match", etag)
+			conditional = append(conditional, "If-None-Match")
+		}
+		if lm := stored.Header.Peek("Last-Modified"); len(lm) > 0 && len(peekHeader(req.Header.All(), "If-Modified-Since")) == 0 {
+			req.Header.SetBytesV("If-Modified-Since", lm)
+			conditional = append(conditional, "If-Modified-Since")
+		}
+	}
+
+	requestTime := time.Now()
+	timing, err := transport.RoundTrip(cli, req, resp)
+	for _, header := range conditional {
+		req.Header.Del(header)
+	}
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+
+	if len(conditional) > 0 && resp.StatusCode() == fasthttp.StatusNotModified {
+		// 使用 304 响应中的响应头更新缓存
+		for k, v := range resp.Header.All() {
+			switch strings.ToLower(string(k)) {
+			case "content-length", "transfer-encoding", "connection":
+			default:
+				stored.Header.SetBytesKV(k, v)
+			}
+		}
+		c.save(key, req, stored, requestTime, responseTime)
+		stored.CopyTo(resp)
+		return &exchange{timing: timing, cacheStatus: CacheRevalidated}, nil
+	}
+
+	if cacheable(resp) {
+		c.save(key, req, resp, requestTime, responseTime)
+	} else if entry != nil {
+		c.store.Delete(key)
+	}
+	return &exchange{timing: timing, cacheStatus: CacheMiss}, nil
+}
+
+// load 读取缓存，Vary 指定的请求头与当前请求不一致时视为未命中
+func (c *responseCache) load(key string, req *fasthttp.Request) *cacheEntry {
+	value, ok := c.store.Get(key)
+	if !ok {
+		return nil
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(value, entry); err != nil {
+		c.store.Delete(key)
+		return nil
+	}
+	for header, v := range entry.Vary {
+		if string(peekHeader(req.Header.All(), header)) != v {
+			return nil
+		}
+	}
+	return entry
+}
+
+// save 保存响应到缓存
+func (c *responseCache) save(key string, req *fasthttp.Request, resp *fasthttp.Response, requestTime, responseTime time.Time) {
+	entry := &cacheEntry{RequestTime: requestTime, ResponseTime: responseTime}
+	if vary := peekHeader(resp.Header.All(), "Vary"); len(vary) > 0 {
+		entry.Vary = make(map[string]string)
+		for _, header := range strings.Split(string(vary), ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				entry.Vary[header] = string(peekHeader(req.Header.All(), header))
+			}
+		}
+	}
+
+	tmp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(tmp)
+	resp.CopyTo(tmp)
+	body := tmp.Body()
+	tmp.Header.SetContentLength(len(body))
+	entry.Response = append(tmp.Header.Header(), body...)
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.store.Set(key, value)
+}
+
+// restore 将缓存的响应报文还原到 resp
+func (e *cacheEntry) restore(resp *fasthttp.Response) error {
+	resp.Reset()
+	return resp.Read(bufio.NewReader(bytes.NewReader(e.Response)))
+}
+
+// age 计算缓存响应的当前年龄（RFC 9111 4.2.3）
+func (e *cacheEntry) age(resp *fasthttp.Response, now time.Time) time.Duration {
+	apparentAge := time.Duration(0)
+	if date, err := fasthttp.ParseHTTPDate(resp.Header.Peek("Date")); err == nil {
+		apparentAge = max(0, e.ResponseTime.Sub(date))
+	}
+
+	ageValue := time.Duration(0)
+	if v, err := strconv.Atoi(string(resp.Header.Peek("Age"))); err == nil && v > 0 {
+		ageValue = time.Duration(v) * time.Second
+	}
+
+	correctedAge := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparentAge, correctedAge) + now.Sub(e.ResponseTime)
+}
+
+// lifetime 计算缓存响应的新鲜期（RFC 9111 4.2.1）
+func (e *cacheEntry) lifetime(resp *fasthttp.Response) time.Duration {
+	cc := parseCacheControl(resp.Header.Peek("Cache-Control"))
+	if cc.has("no-cache") {
+		return 0
+	}
+	if maxAge, ok := cc.duration("max-age"); ok {
+		return maxAge
+	}
+
+	date, err := fasthttp.ParseHTTPDate(resp.Header.Peek("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+
+	if expires := resp.Header.Peek("Expires"); len(expires) > 0 {
+		t, err := fasthttp.ParseHTTPDate(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	if heuristicStatusCodes[resp.StatusCode()] {
+		if lm, err := fasthttp.ParseHTTPDate(resp.Header.Peek("Last-Modified")); err == nil && date.After(lm) {
+			return date.Sub(lm) / 10
+		}
+	}
+	return 0
+}
+
+// fresh 判断缓存是否可以直接使用，会考虑请求中的 max-age、min-fresh、max-stale
+func (e *cacheEntry) fresh(resp *fasthttp.Response, reqCC cacheControl, now time.Time) bool {
+	age := e.age(resp, now)
+	lifetime := e.lifetime(resp)
+
+	if maxAge, ok := reqCC.duration("max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := reqCC.duration("min-fresh"); ok {
+		age += minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+
+	respCC := parseCacheControl(resp.Header.Peek("Cache-Control"))
+	if !reqCC.has("max-stale") || respCC.has("must-revalidate") || respCC.has("no-cache") {
+		return false
+	}
+	maxStale, ok := reqCC.duration("max-stale")
+	return !ok || age-lifetime <= maxStale
+}
+
+// cacheable 判断响应是否可以缓存
+func cacheable(resp *fasthttp.Response) bool {
+	cc := parseCacheControl(peekHeader(resp.Header.All(), "Cache-Control"))
+	if cc.has("no-store") || strings.TrimSpace(string(peekHeader(resp.Header.All(), "Vary"))) == "*" {
+		return false
+	}
+	status := resp.StatusCode()
+	if status == fasthttp.StatusPartialContent || status == fasthttp.StatusNotModified {
+		return false
+	}
+
+	// 明确指定了新鲜期，或者可以启发式缓存且能够进行条件请求验证
+	if cc.has("max-age") || len(peekHeader(resp.Header.All(), "Expires")) > 0 {
+		return true
+	}
+	return heuristicStatusCodes[status] &&
+		(len(peekHeader(resp.Header.All(), "ETag")) > 0 || len(peekHeader(resp.Header.All(), "Last-Modified")) > 0)
+}
+
+// peekHeader 忽略大小写获取头部字段的值，Client 默认不规范化头部字段名称，无法直接使用 Peek
+func peekHeader(headers iter.Seq2[[]byte, []byte], key string) []byte {
+	for k, v := range headers {
+		if strings.EqualFold(string(k), key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// cacheControl Cache-Control 指令
+type cacheControl map[string]string
+
+func parseCacheControl(value []byte) cacheControl {
+	cc := cacheControl{}
+	for _, directive := range strings.Split(string(value), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(directive, "=")
+		cc[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// duration 获取以秒为单位的指令值
+func (cc cacheControl) duration(directive string) (time.Duration, bool) {
+	v, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
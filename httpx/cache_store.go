@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheStore 响应缓存的存储，value 为序列化后的缓存条目
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// MemoryCacheStore 基于 LRU 淘汰策略的内存缓存
+type MemoryCacheStore struct {
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	mu         sync.Mutex
+}
+
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCacheStore 创建内存缓存，maxEntries 为最大缓存条目数，为 0 时不限制
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCacheStore) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.items[key]; ok {
+		m.ll.MoveToFront(e)
+		return e.Value.(*memoryCacheItem).value, true
+	}
+	return nil, false
+}
+
+func (m *MemoryCacheStore) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.items[key]; ok {
+		m.ll.MoveToFront(e)
+		e.Value.(*memoryCacheItem).value = value
+		return
+	}
+
+	m.items[key] = m.ll.PushFront(&memoryCacheItem{key: key, value: value})
+	if m.maxEntries > 0 && m.ll.Len() > m.maxEntries {
+		oldest := m.ll.Back()
+		m.ll.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (m *MemoryCacheStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.items[key]; ok {
+		m.ll.Remove(e)
+		delete(m.items, key)
+	}
+}
+
+// Len 获取当前缓存条目数
+func (m *MemoryCacheStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+// DiskCacheStore 基于文件的磁盘缓存，每个缓存条目保存为一个文件
+type DiskCacheStore struct {
+	dir string
+}
+
+// NewDiskCacheStore 创建磁盘缓存，目录不存在时自动创建
+func NewDiskCacheStore(dir string) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	return &DiskCacheStore{dir: dir}, nil
+}
+
+// path 缓存 key 对应的文件路径
+func (d *DiskCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCacheStore) Get(key string) ([]byte, bool) {
+	value, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (d *DiskCacheStore) Set(key string, value []byte) {
+	// 先写入临时文件再重命名，避免并发读取到不完整的内容
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err = os.Rename(tmp.Name(), d.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (d *DiskCacheStore) Delete(key string) {
+	_ = os.Remove(d.path(key))
+}
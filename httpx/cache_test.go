@@ -0,0 +1,83 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		_, _ = w.Write([]byte(r.URL.Path + " " + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	check := func(req *Request, path string, status CacheStatus, count int32) {
+		t.Helper()
+		resp, err := req.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.CacheStatus() != status || hits.Load() != count || resp.Status() != 200 {
+			t.Fatalf("%s: expected %q with %d hits, got %q with %d hits", path, status, count, resp.CacheStatus(), hits.Load())
+		}
+	}
+
+	client := NewClient().SetCache(NewMemoryCacheStore(100))
+	check(client.R(), "/fresh", CacheMiss, 1)
+	check(client.R(), "/fresh", CacheHit, 1)
+	check(client.R().DisableCache(), "/fresh", CacheNone, 2)
+	check(client.R().SetHeader("Cache-Control", "no-cache"), "/fresh", CacheMiss, 3)
+
+	check(client.R(), "/etag", CacheMiss, 4)
+	check(client.R(), "/etag", CacheRevalidated, 5)
+
+	check(client.R().SetHeader("Accept-Language", "en"), "/vary", CacheMiss, 6)
+	check(client.R().SetHeader("Accept-Language", "en"), "/vary", CacheHit, 6)
+	check(client.R().SetHeader("Accept-Language", "zh"), "/vary", CacheMiss, 7)
+
+	// 不安全的请求方法使缓存失效
+	if _, err := client.R().Post(server.URL + "/fresh"); err != nil {
+		t.Fatal(err)
+	}
+	check(client.R(), "/fresh", CacheMiss, 9)
+
+	store, err := NewDiskCacheStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewClient().SetCache(store)
+	check(client.R(), "/fresh", CacheMiss, 10)
+	check(client.R(), "/fresh", CacheHit, 10)
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	store.Set("a", []byte("1"))
+	store.Set("b", []byte("2"))
+	store.Get("a")
+	store.Set("c", []byte("3"))
+	if _, ok := store.Get("b"); ok || store.Len() != 2 {
+		t.Fatal("expected b to be evicted")
+	}
+	if v, ok := store.Get("a"); !ok || string(v) != "1" {
+		t.Fatal("expected a to be kept")
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"github.com/kelesec/proxyclient"
 	"github.com/valyala/fasthttp"
+	"io"
 	"net"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -28,12 +30,49 @@ type Client struct {
 	TLSConfig                     *tls.Config       // 证书相关配置
 	fastClient                    *fasthttp.Client  // 确保请求只用到一个Client就行
 
+	// 通过 `fasthttp.Client` 创建时用户自定义的 ConfigureClient
+	configureClient func(hc *fasthttp.HostClient) error
+	// fastClient 的 ConfigureClient 是否已被接管
+	hostClientConfigured bool
+	// 默认的连接建立器，Dial 被替换（如设置代理）后 DNS 相关配置不再生效
+	dialer *Dialer
+	// 请求传输层，为 nil 时使用 DefaultTransport
+	transport Transport
+	// 响应缓存，为 nil 时不启用
+	cache *responseCache
+	// 按主机区分的熔断器，为 nil 时不启用
+	breaker *circuitBreaker
+	// 流式请求（如 SSE）使用的传输层，传输层不是 HttpTransport 时在首次使用时创建
+	streamTransport *HttpTransport
+	// 请求指标收集器，为 nil 时不统计
+	metrics *Metrics
+	// 请求日志，为 nil 时不记录
+	logging *requestLogger
+	// 带宽限制与统计，为 nil 时不包装连接
+	bandwidth *bandwidthLimiter
+	// 是否记录连接上的明文，以及记录的写入位置
+	wireDump       bool
+	wireDumpWriter *wireWriter
+	// 按目标地址选择代理，为 nil 时使用 Dial 建立连接
+	proxy *proxyRouter
+
+	// 会话级别的默认配置，由 `R()` 创建的请求继承
+	baseURL           string
+	commonHeaders     fasthttp.RequestHeader
+	commonCookies     map[string]string
+	commonQueryParams url.Values
+	commonAuth        *BasicAuth
+	orderedHeaders    bool
+	// 浏览器特征，由 `R()` 创建的请求从中随机选择一个使用
+	profiles []*BrowserProfile
+
 	// 加个锁
 	clock *sync.Mutex
 }
 
 // NewClient 使用默认配置创建 Client
 func NewClient() *Client {
+	dialer := NewDialer()
 	return &Client{
 		ReadTimeout:                   time.Second * 10,
 		WriteTimeout:                  time.Second * 10,
@@ -49,11 +88,9 @@ func NewClient() *Client {
 		TLSConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
-		Dial: (&fasthttp.TCPDialer{
-			Concurrency:      4096,
-			DNSCacheDuration: time.Hour,
-		}).Dial,
+		Dial:       dialer.Dial,
 		fastClient: &fasthttp.Client{},
+		dialer:     dialer,
 		clock:      &sync.Mutex{},
 	}
 }
@@ -75,6 +112,8 @@ func NewClientWithFastHttp(client *fasthttp.Client) *Client {
 		Dial:                          client.Dial,
 		TLSConfig:                     client.TLSConfig,
 		fastClient:                    client,
+		configureClient:               client.ConfigureClient,
+		dialer:                        NewDialer(),
 		clock:                         &sync.Mutex{},
 	}
 }
@@ -93,26 +132,101 @@ func (cli *Client) preCheck() {
 		cli.fastClient = &fasthttp.Client{}
 	}
 
-	// 将内容全部同步给 fastClient
-	cli.fastClient.ReadTimeout = cli.ReadTimeout
-	cli.fastClient.WriteTimeout = cli.WriteTimeout
-	cli.fastClient.MaxIdleConnDuration = cli.MaxIdleConnDuration
-	cli.fastClient.MaxConnWaitTimeout = cli.MaxConnWaitTimeout
-	cli.fastClient.ReadBufferSize = cli.ReadBufferSize
-	cli.fastClient.WriteBufferSize = cli.WriteBufferSize
-	cli.fastClient.MaxResponseBodySize = cli.MaxResponseBodySize
-	cli.fastClient.MaxConnsPerHost = cli.MaxConnsPerHost
-	cli.fastClient.NoDefaultUserAgentHeader = cli.NoDefaultUserAgentHeader
-	cli.fastClient.DisableHeaderNamesNormalizing = cli.DisableHeaderNamesNormalizing
-	cli.fastClient.DisablePathNormalizing = cli.DisablePathNormalizing
-	cli.fastClient.Dial = cli.Dial
-	cli.fastClient.TLSConfig = cli.TLSConfig
+	// 将内容全部同步给 fastClient，只写入发生变化的配置，避免并发请求时与 fasthttp 的后台协程产生数据竞争
+	syncField(&cli.fastClient.ReadTimeout, cli.ReadTimeout)
+	syncField(&cli.fastClient.WriteTimeout, cli.WriteTimeout)
+	syncField(&cli.fastClient.MaxIdleConnDuration, cli.MaxIdleConnDuration)
+	syncField(&cli.fastClient.MaxConnWaitTimeout, cli.MaxConnWaitTimeout)
+	syncField(&cli.fastClient.ReadBufferSize, cli.ReadBufferSize)
+	syncField(&cli.fastClient.WriteBufferSize, cli.WriteBufferSize)
+	syncField(&cli.fastClient.MaxResponseBodySize, cli.MaxResponseBodySize)
+	syncField(&cli.fastClient.MaxConnsPerHost, cli.MaxConnsPerHost)
+	syncField(&cli.fastClient.NoDefaultUserAgentHeader, cli.NoDefaultUserAgentHeader)
+	syncField(&cli.fastClient.DisableHeaderNamesNormalizing, cli.DisableHeaderNamesNormalizing)
+	syncField(&cli.fastClient.DisablePathNormalizing, cli.DisablePathNormalizing)
+	syncField(&cli.fastClient.TLSConfig, cli.TLSConfig)
+
+	// 连接由 configureHostClient 接管，建立连接时才读取 Dial，因此只需同步一次
+	if !cli.hostClientConfigured {
+		cli.fastClient.Dial = cli.Dial
+		cli.fastClient.ConfigureClient = cli.configureHostClient
+		cli.hostClientConfigured = true
+	}
 }
 
-// execute 执行 HTTP 请求
-func (cli *Client) execute(req *fasthttp.Request, resp *fasthttp.Response) error {
-	cli.preCheck()
-	return cli.fastClient.Do(req, resp)
+// syncField 值发生变化时才写入
+func syncField[T comparable](dst *T, value T) {
+	if *dst != value {
+		*dst = value
+	}
+}
+
+// configureHostClient 接管每个 `fasthttp.HostClient` 的连接建立过程，用于统计各阶段耗时
+func (cli *Client) configureHostClient(hc *fasthttp.HostClient) error {
+	if cli.configureClient != nil {
+		if err := cli.configureClient(hc); err != nil {
+			return err
+		}
+	}
+	hc.Dial = cli.dialHost(hc)
+	hc.DialTimeout = nil
+	return nil
+}
+
+// exchange 单次请求（重定向时为单跳）的执行结果
+type exchange struct {
+	timing      *Timing     // 各阶段耗时
+	cacheStatus CacheStatus // 缓存状态
+}
+
+// execute 通过传输层执行 HTTP 请求，noCache 为 true 时跳过响应缓存
+func (cli *Client) execute(req *fasthttp.Request, resp *fasthttp.Response, noCache bool) (*exchange, error) {
+	cli.clock.Lock()
+	transport := cli.transport
+	cache := cli.cache
+	breaker := cli.breaker
+	metrics := cli.metrics
+	logging := cli.logging
+	cli.clock.Unlock()
+
+	if transport == nil {
+		transport = DefaultTransport
+	}
+	if metrics == nil && logging == nil {
+		return cli.roundTrip(transport, cache, breaker, req, resp, noCache)
+	}
+
+	var host string
+	if metrics != nil {
+		host = metricsHost(req)
+		metrics.begin(host)
+	}
+	start := time.Now()
+	ex, err := cli.roundTrip(transport, cache, breaker, req, resp, noCache)
+	latency := time.Since(start)
+	if metrics != nil {
+		metrics.done(host, string(req.Header.Method()), resp.StatusCode(), len(resp.Body()), latency, err)
+	}
+	if logging != nil {
+		logging.log(req, resp, ex, latency, err)
+	}
+	return ex, err
+}
+
+// roundTrip 经过响应缓存与熔断器执行请求，命中缓存的请求不经过熔断器
+func (cli *Client) roundTrip(transport Transport, cache *responseCache, breaker *circuitBreaker,
+	req *fasthttp.Request, resp *fasthttp.Response, noCache bool) (*exchange, error) {
+	if breaker != nil {
+		transport = breakerTransport{Transport: transport, breaker: breaker}
+	}
+	if cache != nil && !noCache {
+		return cache.roundTrip(cli, transport, req, resp)
+	}
+	timing, err := transport.RoundTrip(cli, req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return &exchange{timing: timing}, nil
 }
 
 func (cli *Client) SetReadTimeout(t time.Duration) *Client {
@@ -199,10 +313,176 @@ func (cli *Client) SetTLSConfig(c *tls.Config) *Client {
 	return cli
 }
 
+// SetTransport 设置请求传输层，如使用 `NewHttpTransport()` 以支持 HTTP/2
+func (cli *Client) SetTransport(t Transport) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.transport = t
+	return cli
+}
+
+// SetCache 启用响应缓存，遵循 Cache-Control、Expires 并使用 ETag/Last-Modified 进行验证，
+// 可使用 `NewMemoryCacheStore` 或 `NewDiskCacheStore`，传入 nil 时关闭缓存
+func (cli *Client) SetCache(store CacheStore) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if store == nil {
+		cli.cache = nil
+	} else {
+		cli.cache = &responseCache{store: store}
+	}
+	return cli
+}
+
+// SetCircuitBreaker 启用按主机区分的熔断器，熔断器打开时请求直接返回 `*CircuitOpenError`，
+// 可通过 errors.Is(err, ErrCircuitOpen) 判断，传入 nil 时关闭熔断器
+func (cli *Client) SetCircuitBreaker(config *BreakerConfig) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if config == nil {
+		cli.breaker = nil
+	} else {
+		cli.breaker = newCircuitBreaker(*config)
+	}
+	return cli
+}
+
+// SetMetrics 设置请求指标收集器，同一个收集器可以在多个 Client 之间共享，传入 nil 时关闭统计
+func (cli *Client) SetMetrics(m *Metrics) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.metrics = m
+	return cli
+}
+
+// Metrics 获取请求指标收集器，未设置时返回 nil
+func (cli *Client) Metrics() *Metrics {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	return cli.metrics
+}
+
+// SetLogger 通过 logger 包记录每次请求的日志（重定向时为每一跳），摘要与完整的请求、响应分别按配置的级别输出，
+// 认证信息、Cookie 以及配置的字段会被脱敏，传入 nil 时关闭日志
+func (cli *Client) SetLogger(config *LogConfig) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if config == nil {
+		cli.logging = nil
+	} else {
+		cli.logging = newRequestLogger(*config)
+	}
+	return cli
+}
+
+// SetBandwidthLimit 限制所有连接的总带宽（字节/秒），up 为上传、down 为下载，为 0 时不限制。
+// 通过包装 Dial 返回的连接实现，对直连、代理以及 TLS 连接同样生效，设置之前建立的连接不受限制，
+// 调用后（包括均为 0 时）开始统计流量，可通过 `BandwidthStats` 获取
+func (cli *Client) SetBandwidthLimit(up, down int64) *Client {
+	if up < 0 || down < 0 {
+		panic(fmt.Errorf("invalid bandwidth limit: up=%d down=%d", up, down))
+	}
+	b := cli.bandwidthLimiter()
+	b.up.setRate(up)
+	b.down.setRate(down)
+	return cli
+}
+
+// SetHostBandwidthLimit 限制单个主机的带宽（字节/秒），host 可以是 `host:port` 或 `host`，
+// 与全局限制同时生效，均为 0 时取消限制。使用代理时主机为目标主机
+func (cli *Client) SetHostBandwidthLimit(host string, up, down int64) *Client {
+	if up < 0 || down < 0 {
+		panic(fmt.Errorf("invalid bandwidth limit: up=%d down=%d", up, down))
+	}
+	cli.bandwidthLimiter().setHostLimit(host, up, down)
+	return cli
+}
+
+// BandwidthStats 获取实际的流量与平均速率，未设置带宽限制时返回空的统计
+func (cli *Client) BandwidthStats() *BandwidthStats {
+	cli.clock.Lock()
+	b := cli.bandwidth
+	cli.clock.Unlock()
+	if b == nil {
+		return &BandwidthStats{Hosts: map[string]Throughput{}}
+	}
+	return b.snapshot()
+}
+
+// bandwidthLimiter 获取带宽限制，不存在时创建
+func (cli *Client) bandwidthLimiter() *bandwidthLimiter {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if cli.bandwidth == nil {
+		cli.bandwidth = newBandwidthLimiter()
+	}
+	return cli.bandwidth
+}
+
+// SetWireDump 开启后记录每次请求在连接上实际发送与接收的明文字节（TLS 加密之前、解密之后，不包括代理协商），
+// 可通过 `Response.WireDump` 获取，对已建立的连接同样生效。默认的传输层、`HttpTransport`、SSE 与 WebSocket 握手均会记录，
+// HTTP/2 连接不记录，每次请求最多记录 1MB。仅用于调试
+func (cli *Client) SetWireDump(enable bool) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.wireDump = enable
+	return cli
+}
+
+// SetWireDumpWriter 开启明文记录，并在每次请求完成后将记录写入 w，多个请求的记录不会交错，传入 nil 时不再写入
+func (cli *Client) SetWireDumpWriter(w io.Writer) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if w == nil {
+		cli.wireDumpWriter = nil
+	} else {
+		cli.wireDump = true
+		cli.wireDumpWriter = &wireWriter{w: w}
+	}
+	return cli
+}
+
+// wireDumpEnabled 是否记录连接上的明文
+func (cli *Client) wireDumpEnabled() bool {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	return cli.wireDump
+}
+
+// writeWireDump 将一次请求的明文记录写入 SetWireDumpWriter 设置的位置
+func (cli *Client) writeWireDump(wire []byte) {
+	cli.clock.Lock()
+	w := cli.wireDumpWriter
+	cli.clock.Unlock()
+	if w != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		_, _ = w.w.Write(wire)
+	}
+}
+
+// wireWriter 串行写入明文记录
+type wireWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// BreakerState 获取主机（与 URL 中的 host 一致，如 example.com、127.0.0.1:8080）当前的熔断状态，未启用熔断器时始终为 BreakerClosed
+func (cli *Client) BreakerState(host string) BreakerState {
+	cli.clock.Lock()
+	breaker := cli.breaker
+	cli.clock.Unlock()
+	if breaker == nil {
+		return BreakerClosed
+	}
+	return breaker.state(host)
+}
+
 func (cli *Client) SetDial(f fasthttp.DialFunc) *Client {
 	cli.clock.Lock()
 	defer cli.clock.Unlock()
 	cli.Dial = f
+	cli.proxy = nil
 	return cli
 }
 
@@ -225,6 +505,7 @@ func (cli *Client) SetProxy(proxy string) *Client {
 	cli.Dial = func(addr string) (net.Conn, error) {
 		return dial.Dial("tcp", addr)
 	}
+	cli.proxy = nil
 
 	return cli
 }
@@ -252,6 +533,196 @@ func (cli *Client) SetProxies(proxies []string) *Client {
 	cli.Dial = func(addr string) (net.Conn, error) {
 		return dialer.Dial("tcp", addr)
 	}
+	cli.proxy = nil
+
+	return cli
+}
+
+// SetProxySelector 按目标地址选择代理，未选择代理时通过默认的连接建立器直接连接，selector 为 nil 时取消。
+// 明文 HTTP 请求使用 http 代理时以绝对形式发送给代理，其余通过隧道连接。
+// 会覆盖 `SetProxy`、`SetProxies`、`SetDial` 的设置，反之亦然
+func (cli *Client) SetProxySelector(selector ProxySelector) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.Dial = cli.dialer.Dial
+	cli.proxy = nil
+	if selector != nil {
+		cli.proxy = newProxyRouter(func(addr string, _ bool) (*url.URL, error) {
+			return selector(addr)
+		})
+	}
+	return cli
+}
+
+// SetProxyFromEnvironment 根据环境变量 HTTP_PROXY、HTTPS_PROXY、ALL_PROXY 以及 NO_PROXY 选择代理，
+// 环境变量在调用时读取，NO_PROXY 的格式参考 `parseNoProxy`，设置了 REQUEST_METHOD（CGI 环境）时忽略 HTTP_PROXY，
+// 其余同 `SetProxySelector`
+func (cli *Client) SetProxyFromEnvironment() *Client {
+	env, err := loadProxyEnv()
+	if err != nil {
+		panic(err)
+	}
+
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.Dial = cli.dialer.Dial
+	cli.proxy = newProxyRouter(env.proxy)
+	return cli
+}
+
+// DefaultDialer 获取默认的连接建立器，可用于进一步自定义 DNS 相关配置
+func (cli *Client) DefaultDialer() *Dialer {
+	return cli.dialer
+}
+
+// SetResolver 设置 DNS 服务器，支持 UDP、TCP 以及 DoH，格式参考 `NewResolver`，
+// 仅对默认的连接建立器生效，使用代理时由代理服务器负责解析
+func (cli *Client) SetResolver(servers ...string) *Client {
+	resolver, err := NewResolver(servers...)
+	if err != nil {
+		panic(fmt.Errorf("create resolver failed: %s", err))
+	}
+	cli.dialer.SetResolver(resolver)
+	return cli
+}
+
+// SetLocalAddr 设置建立连接时使用的本地源地址，多个地址时轮流使用，目标地址只会使用相同协议族的源地址，
+// 仅对默认的连接建立器生效，使用代理时不生效
+func (cli *Client) SetLocalAddr(ips ...string) *Client {
+	cli.dialer.SetLocalAddrs(ips...)
+	return cli
+}
+
+// SetIPFamily 设置 IP 协议族策略，如只使用 IPv4、只使用 IPv6、优先 IPv4 或 HappyEyeballs 并发竞速，
+// 仅对默认的连接建立器生效
+func (cli *Client) SetIPFamily(family IPFamily) *Client {
+	cli.dialer.SetIPFamily(family)
+	return cli
+}
+
+// SetHostOverride 设置静态解析记录，类似 curl 的 --resolve，host 可以是 `host:port` 或 `host`
+func (cli *Client) SetHostOverride(host, ip string) *Client {
+	cli.dialer.SetHostOverride(host, ip)
+	return cli
+}
+
+// SetHostOverrides 批量设置静态解析记录
+func (cli *Client) SetHostOverrides(overrides map[string]string) *Client {
+	for host, ip := range overrides {
+		cli.SetHostOverride(host, ip)
+	}
+	return cli
+}
+
+// SetDNSCacheDuration 设置 DNS 解析成功与解析失败结果的缓存时间，为 0 时不缓存
+func (cli *Client) SetDNSCacheDuration(ttl, negativeTTL time.Duration) *Client {
+	cli.dialer.SetDNSCacheDuration(ttl, negativeTTL)
+	return cli
+}
+
+// SetBaseURL 设置基础 URL，请求地址为相对路径（如 `/api/x`）时拼接在基础 URL 之后，
+// 请求地址为空时直接请求基础 URL
+func (cli *Client) SetBaseURL(baseURL string) *Client {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(fmt.Errorf("invalid base url: %s", baseURL))
+	}
+
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.baseURL = strings.TrimRight(baseURL, "/")
+	return cli
+}
+
+// SetCommonHeader 设置所有请求默认携带的请求头
+func (cli *Client) SetCommonHeader(key, value string) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.commonHeaders.Set(key, value)
+	return cli
+}
+
+// SetCommonHeaders 设置所有请求默认携带的请求头
+func (cli *Client) SetCommonHeaders(headers map[string]string) *Client {
+	for k, v := range headers {
+		cli.SetCommonHeader(k, v)
+	}
+	return cli
+}
+
+// SetCommonCookie 设置所有请求默认携带的 Cookie
+func (cli *Client) SetCommonCookie(key, value string) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if cli.commonCookies == nil {
+		cli.commonCookies = make(map[string]string)
+	}
+	cli.commonCookies[key] = value
+	return cli
+}
+
+// SetCommonCookies 设置所有请求默认携带的 Cookie
+func (cli *Client) SetCommonCookies(cookies map[string]string) *Client {
+	for k, v := range cookies {
+		cli.SetCommonCookie(k, v)
+	}
+	return cli
+}
+
+// SetCommonQueryParam 设置所有请求默认携带的 URL 请求参数
+func (cli *Client) SetCommonQueryParam(key, value string) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if cli.commonQueryParams == nil {
+		cli.commonQueryParams = url.Values{}
+	}
+	cli.commonQueryParams.Set(key, value)
+	return cli
+}
+
+// SetCommonQueryParams 设置所有请求默认携带的 URL 请求参数
+func (cli *Client) SetCommonQueryParams(params map[string]string) *Client {
+	for k, v := range params {
+		cli.SetCommonQueryParam(k, v)
+	}
+	return cli
+}
+
+// SetCommonAuth 设置所有请求默认使用的 Basic 认证
+func (cli *Client) SetCommonAuth(username, password string) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.commonAuth = &BasicAuth{Username: username, Password: password}
+	return cli
+}
+
+// SetOrderedHeaders 设置所有请求默认使用有序请求头模式，参考 `Request.SetOrderedHeaders`
+func (cli *Client) SetOrderedHeaders(b bool) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.orderedHeaders = b
+	return cli
+}
+
+// SetProfile 设置所有请求使用的浏览器特征，nil 表示不使用，使用浏览器特征的请求会开启有序请求头模式
+func (cli *Client) SetProfile(profile *BrowserProfile) *Client {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.profiles = nil
+	if profile != nil {
+		cli.profiles = []*BrowserProfile{profile}
+	}
+	return cli
+}
 
+// SetRandomProfile 每个请求从给定的浏览器特征中随机选择一个使用，未指定时使用所有内置的浏览器特征，
+// 可通过 `LoadProfiles` 从 UA 列表文件中加载
+func (cli *Client) SetRandomProfile(profiles ...*BrowserProfile) *Client {
+	if len(profiles) == 0 {
+		profiles = Profiles
+	}
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	cli.profiles = append([]*BrowserProfile(nil), profiles...)
 	return cli
 }
@@ -0,0 +1,67 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientCommonDefaults(t *testing.T) {
+	var last *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+	}))
+	defer server.Close()
+
+	client := NewClient().
+		SetBaseURL(server.URL+"/v1/").
+		SetCommonHeaders(map[string]string{"X-Api-Key": "key", "X-Trace": "1"}).
+		SetCommonCookies(map[string]string{"session": "abc"}).
+		SetCommonQueryParams(map[string]string{"token": "t", "lang": "en"}).
+		SetCommonAuth("user", "pass")
+
+	if _, err := client.R().Get("/users"); err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.Path != "/v1/users" || last.Header.Get("X-Api-Key") != "key" ||
+		last.URL.Query().Get("token") != "t" || last.Header.Get("Authorization") == "" {
+		t.Fatalf("defaults not inherited: %s %v", last.URL, last.Header)
+	}
+	if cookie, err := last.Cookie("session"); err != nil || cookie.Value != "abc" {
+		t.Fatalf("expected session cookie, got %v", last.Header.Get("Cookie"))
+	}
+
+	// 单个请求覆盖或移除默认配置，且不影响 Client
+	_, err := client.R().
+		SetHeader("X-Api-Key", "other").
+		DelHeader("X-Trace").
+		DelCookie("session").
+		SetQueryParam("lang", "zh").
+		DelQueryParam("token").
+		DelBasicAuth().
+		Get("items?page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := last.URL.Query()
+	if last.URL.Path != "/v1/items" || last.Header.Get("X-Api-Key") != "other" || last.Header.Get("X-Trace") != "" ||
+		last.Header.Get("Cookie") != "" || last.Header.Get("Authorization") != "" ||
+		query.Get("lang") != "zh" || query.Has("token") || query.Get("page") != "2" {
+		t.Fatalf("defaults not overridden: %s %v", last.URL, last.Header)
+	}
+
+	// 查询参数中包含 :// 的相对路径仍然拼接在基础 URL 之后
+	if _, err = client.R().Get("/cb?next=http://x"); err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.Path != "/v1/cb" || last.URL.Query().Get("next") != "http://x" {
+		t.Fatalf("relative url should use base url: %s", last.URL)
+	}
+
+	if _, err = client.R().Get(server.URL + "/abs"); err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.Path != "/abs" || last.Header.Get("X-Trace") != "1" {
+		t.Fatalf("absolute url should ignore base url: %s %v", last.URL, last.Header)
+	}
+}
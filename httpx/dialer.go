@@ -0,0 +1,564 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IPFamily 建立连接时使用的 IP 协议族策略
+type IPFamily int
+
+const (
+	IPFamilyDefault IPFamily = iota // 由 DualStack 决定，未开启时只使用 IPv4，开启时按解析顺序依次尝试
+	IPv4Only                        // 只使用 IPv4
+	IPv6Only                        // 只使用 IPv6
+	PreferIPv4                      // 优先使用 IPv4，失败后依次尝试 IPv6
+	HappyEyeballs                   // 参考 RFC 8305，IPv6 与 IPv4 交替排列，每隔 HappyEyeballsDelay 并发发起下一个连接，使用最先建立的连接
+)
+
+// HappyEyeballsDelay HappyEyeballs 策略下发起下一个连接前的等待时间
+const HappyEyeballsDelay = 250 * time.Millisecond
+
+// Dialer 默认的连接建立器，将 DNS 解析与 TCP 建连拆开执行，便于统计各阶段耗时
+type Dialer struct {
+	Timeout                  time.Duration // 单次建连超时（包含 DNS 解析），默认 fasthttp.DefaultDialTimeout
+	DNSCacheDuration         time.Duration // DNS 解析结果缓存时间，为 0 时不缓存
+	DNSNegativeCacheDuration time.Duration // DNS 解析失败结果缓存时间，为 0 时不缓存
+	DualStack                bool          // 是否允许使用 IPv6 地址，默认只使用 IPv4，设置 IPFamily 后不再生效
+	IPFamily                 IPFamily      // IP 协议族策略
+	LocalAddrs               []net.IP      // 本地源地址，多个时轮流使用，按目标地址的协议族选择
+	Resolver                 Resolver      // 域名解析器，为 nil 时使用系统解析器
+
+	tcpDialer *fasthttp.TCPDialer
+	overrides map[string]net.IP // 静态解析记录，类似 curl 的 --resolve
+	cache     map[string]*dnsCacheEntry
+	nextLocal atomic.Uint64 // 轮流使用本地源地址的计数
+	mu        sync.Mutex
+	once      sync.Once
+}
+
+type dnsCacheEntry struct {
+	addrs   []net.IP
+	err     error
+	expires time.Time
+}
+
+// dialedConn 默认 Dialer 建立的连接，携带了 DNS 解析与 TCP 建连耗时
+type dialedConn struct {
+	net.Conn
+	dnsLookup  time.Duration
+	tcpConnect time.Duration
+}
+
+// NewDialer 创建默认的 Dialer
+func NewDialer() *Dialer {
+	return &Dialer{
+		Timeout:          fasthttp.DefaultDialTimeout,
+		DNSCacheDuration: time.Hour,
+	}
+}
+
+func (d *Dialer) init() {
+	d.once.Do(func() {
+		d.tcpDialer = &fasthttp.TCPDialer{
+			Concurrency:          4096,
+			DisableDNSResolution: true,
+		}
+		d.cache = make(map[string]*dnsCacheEntry)
+	})
+}
+
+// SetResolver 设置域名解析器，同时清空已有的解析缓存
+func (d *Dialer) SetResolver(r Resolver) *Dialer {
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Resolver = r
+	d.cache = make(map[string]*dnsCacheEntry)
+	return d
+}
+
+// SetDNSCacheDuration 设置解析成功与解析失败结果的缓存时间，为 0 时不缓存
+func (d *Dialer) SetDNSCacheDuration(ttl, negativeTTL time.Duration) *Dialer {
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.DNSCacheDuration = ttl
+	d.DNSNegativeCacheDuration = negativeTTL
+	d.cache = make(map[string]*dnsCacheEntry)
+	return d
+}
+
+// SetHostOverride 设置静态解析记录，host 可以是 `host:port`（仅对该端口生效）或 `host`（对所有端口生效），
+// 用法与 curl 的 --resolve 类似，匹配的连接不再进行 DNS 解析，直接连接 ip
+func (d *Dialer) SetHostOverride(host, ip string) *Dialer {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		panic(fmt.Errorf("invalid ip address: %s", ip))
+	}
+
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.overrides == nil {
+		d.overrides = make(map[string]net.IP)
+	}
+	d.overrides[strings.ToLower(host)] = addr
+	return d
+}
+
+// SetIPFamily 设置 IP 协议族策略，同时清空已有的解析缓存，IP 地址与静态解析记录同样需要符合该策略
+func (d *Dialer) SetIPFamily(family IPFamily) *Dialer {
+	if family < IPFamilyDefault || family > HappyEyeballs {
+		panic(fmt.Errorf("invalid ip family: %d", family))
+	}
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.IPFamily = family
+	d.cache = make(map[string]*dnsCacheEntry)
+	return d
+}
+
+// SetLocalAddrs 设置本地源地址，多个地址时轮流使用，目标地址只会使用相同协议族的源地址，不传入时取消
+func (d *Dialer) SetLocalAddrs(ips ...string) *Dialer {
+	addrs := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			panic(fmt.Errorf("invalid local address: %s", ip))
+		}
+		addrs = append(addrs, addr)
+	}
+
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.LocalAddrs = addrs
+	return d
+}
+
+// DelHostOverride 删除静态解析记录
+func (d *Dialer) DelHostOverride(host string) *Dialer {
+	d.init()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.overrides, strings.ToLower(host))
+	return d
+}
+
+// timeout 获取单次建连超时，未设置时为 fasthttp.DefaultDialTimeout
+func (d *Dialer) timeout() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Timeout <= 0 {
+		return fasthttp.DefaultDialTimeout
+	}
+	return d.Timeout
+}
+
+// Dial 建立与 addr 的 TCP 连接，满足 `fasthttp.DialFunc` 签名
+func (d *Dialer) Dial(addr string) (net.Conn, error) {
+	d.init()
+	deadline := time.Now().Add(d.timeout())
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %v", addr, err)
+	}
+
+	dnsStart := time.Now()
+	ips, err := d.lookup(addr, host, deadline)
+	if err != nil {
+		return nil, err
+	}
+	dnsLookup := time.Since(dnsStart)
+
+	d.mu.Lock()
+	family := d.IPFamily
+	d.mu.Unlock()
+
+	connectStart := time.Now()
+	var conn net.Conn
+	if family == HappyEyeballs && len(ips) > 1 {
+		conn, err = d.dialRace(ips, port, deadline)
+	} else {
+		conn, err = d.dialSerial(ips, port, deadline)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial %s failed: %w", addr, err)
+	}
+	return &dialedConn{
+		Conn:       conn,
+		dnsLookup:  dnsLookup,
+		tcpConnect: time.Since(connectStart),
+	}, nil
+}
+
+// dialSerial 依次尝试连接每个地址
+func (d *Dialer) dialSerial(ips []net.IP, port string, deadline time.Time) (net.Conn, error) {
+	var err error
+	for _, ip := range ips {
+		conn, e := d.dialIP(context.Background(), ip, port, deadline)
+		if e == nil {
+			return conn, nil
+		}
+		err = e
+		if errors.Is(e, fasthttp.ErrDialTimeout) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// dialRace 每隔 HappyEyeballsDelay 或上一个连接失败时发起下一个连接，使用最先建立的连接并关闭其余连接
+func (d *Dialer) dialRace(ips []net.IP, port string, deadline time.Time) (net.Conn, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, len(ips))
+	next, pending := 0, 0
+	start := func() {
+		ip := ips[next]
+		next++
+		pending++
+		go func() {
+			conn, err := d.dialIP(ctx, ip, port, deadline)
+			results <- result{conn, err}
+		}()
+	}
+
+	start()
+	timer := time.NewTimer(HappyEyeballsDelay)
+	defer timer.Stop()
+	var firstErr error
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// 关闭之后建立的连接
+				go func(n int) {
+					for ; n > 0; n-- {
+						if r := <-results; r.conn != nil {
+							_ = r.conn.Close()
+						}
+					}
+				}(pending)
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if next < len(ips) {
+				start()
+				timer.Reset(HappyEyeballsDelay)
+			}
+		case <-timer.C:
+			if next < len(ips) {
+				start()
+				timer.Reset(HappyEyeballsDelay)
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+// dialIP 建立与 ip:port 的 TCP 连接，设置了本地源地址时绑定相同协议族的源地址
+func (d *Dialer) dialIP(ctx context.Context, ip net.IP, port string, deadline time.Time) (net.Conn, error) {
+	addr := net.JoinHostPort(ip.String(), port)
+	local, err := d.localAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	if local == nil && ctx.Done() == nil {
+		// 串行建连且未设置源地址时沿用 fasthttp 的 TCPDialer
+		return d.tcpDialer.DialDualStackTimeout(addr, time.Until(deadline))
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	if local != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: local}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return nil, fasthttp.ErrDialTimeout
+	}
+	return conn, err
+}
+
+// localAddr 轮流选择与 ip 协议族相同的本地源地址，未设置本地源地址时返回 nil
+func (d *Dialer) localAddr(ip net.IP) (net.IP, error) {
+	d.mu.Lock()
+	addrs := d.LocalAddrs
+	d.mu.Unlock()
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	isIPv4 := ip.To4() != nil
+	matched := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if (addr.To4() != nil) == isIPv4 {
+			matched = append(matched, addr)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no local address of the same family as %s", ip)
+	}
+	return matched[(d.nextLocal.Add(1)-1)%uint64(len(matched))], nil
+}
+
+// lookup 解析域名，优先使用静态解析记录，IP 地址直接返回。
+// IP 地址与静态解析记录同样按设置的 IPFamily 过滤（不受 DualStack 影响），不符合时返回错误
+func (d *Dialer) lookup(addr, host string, deadline time.Time) ([]net.IP, error) {
+	d.mu.Lock()
+	resolver, family, explicit := d.Resolver, d.family(), d.IPFamily
+	ttl, negativeTTL := d.DNSCacheDuration, d.DNSNegativeCacheDuration
+	ip, ok := d.overrides[strings.ToLower(addr)]
+	if !ok {
+		ip, ok = d.overrides[strings.ToLower(host)]
+	}
+	entry, cached := d.cache[host]
+	d.mu.Unlock()
+
+	if literal := net.ParseIP(host); literal != nil {
+		return staticIP(host, literal, explicit)
+	}
+	if ok {
+		return staticIP(host, ip, explicit)
+	}
+	if cached && time.Now().Before(entry.expires) {
+		return entry.addrs, entry.err
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ips, err := resolveIP(ctx, resolver, host, family)
+
+	if err != nil {
+		// 超时等临时错误不进行缓存
+		var dnsErr *net.DNSError
+		if negativeTTL > 0 && errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			d.setCache(host, &dnsCacheEntry{err: err, expires: time.Now().Add(negativeTTL)})
+		}
+		return nil, err
+	}
+
+	if ttl > 0 {
+		d.setCache(host, &dnsCacheEntry{addrs: ips, expires: time.Now().Add(ttl)})
+	}
+	return ips, nil
+}
+
+func (d *Dialer) setCache(host string, entry *dnsCacheEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache[host] = entry
+}
+
+// staticIP 按协议族策略检查 IP 地址或静态解析记录
+func staticIP(host string, ip net.IP, family IPFamily) ([]net.IP, error) {
+	if ips := sortIPs([]net.IP{ip}, family); len(ips) > 0 {
+		return ips, nil
+	}
+	return nil, fmt.Errorf("lookup %s failed: %w", host,
+		&net.DNSError{Err: "address " + ip.String() + " does not match the ip family", Name: host, IsNotFound: true})
+}
+
+// family 获取实际生效的协议族策略，IPFamilyDefault 表示按解析顺序使用所有地址
+func (d *Dialer) family() IPFamily {
+	if d.IPFamily == IPFamilyDefault && !d.DualStack {
+		return IPv4Only
+	}
+	return d.IPFamily
+}
+
+// resolveIP 使用解析器解析域名，并按 IP 协议族策略过滤、排序结果
+func resolveIP(ctx context.Context, resolver Resolver, host string, family IPFamily) ([]net.IP, error) {
+	ipAddrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s failed: %w", host, err)
+	}
+
+	ips := make([]net.IP, 0, len(ipAddrs))
+	for _, ipAddr := range ipAddrs {
+		ips = append(ips, ipAddr.IP)
+	}
+	ips = sortIPs(ips, family)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("lookup %s failed: %w", host,
+			&net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true})
+	}
+	return ips, nil
+}
+
+// sortIPs 按协议族策略过滤并排序地址
+func sortIPs(ips []net.IP, family IPFamily) []net.IP {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	switch family {
+	case IPv4Only:
+		return v4
+	case IPv6Only:
+		return v6
+	case PreferIPv4:
+		return append(v4, v6...)
+	case HappyEyeballs:
+		// IPv6 优先，两种协议族交替排列
+		sorted := make([]net.IP, 0, len(ips))
+		for i := 0; i < len(v4) || i < len(v6); i++ {
+			if i < len(v6) {
+				sorted = append(sorted, v6[i])
+			}
+			if i < len(v4) {
+				sorted = append(sorted, v4[i])
+			}
+		}
+		return sorted
+	}
+	return ips
+}
+
+// dialHost 生成 `fasthttp.HostClient` 使用的连接建立函数，统一在此处完成 TLS 握手并记录各阶段耗时
+func (cli *Client) dialHost(hc *fasthttp.HostClient) fasthttp.DialFunc {
+	isTLS := hc.IsTLS
+	return func(addr string) (net.Conn, error) {
+		// 解码请求设置的连接地址与 SNI
+		target, sni, connect, _ := parseRouteAddr(addr)
+		conn, _, err := cli.dialConn(target, connect, isTLS, false, sni, nil)
+		return conn, err
+	}
+}
+
+// dialConn 建立与 addr 的连接，isTLS 为 true 时完成 TLS 握手，返回连接以及建立连接各阶段的耗时，
+// host 为原始请求的 host:port，用于匹配按主机的带宽限制，tunnel 为 true 时明文连接同样通过代理建立隧道。
+// 连接通过 traceConn 记录每次交互的耗时与明文
+func (cli *Client) dialConn(host, addr string, isTLS, tunnel bool, sni string, nextProtos []string) (net.Conn, connTiming, error) {
+	cli.clock.Lock()
+	dial := cli.Dial
+	tlsConfig := cli.TLSConfig
+	handshakeTimeout := cli.WriteTimeout
+	bandwidth := cli.bandwidth
+	proxy := cli.proxy
+	cli.clock.Unlock()
+
+	if dial == nil {
+		dial = cli.dialer.Dial
+	}
+
+	var timing connTiming
+	start := time.Now()
+	var conn net.Conn
+	var err error
+	if socket, ok := parseUnixRouteAddr(addr); ok {
+		// Unix socket 不经过代理与 DNS 解析
+		addr = socket
+		conn, err = net.DialTimeout("unix", socket, cli.dialer.timeout())
+	} else if proxy != nil {
+		conn, err = proxy.dial(host, addr, isTLS, tunnel, dial)
+	} else {
+		conn, err = dial(addr)
+	}
+	if err != nil {
+		return nil, timing, err
+	}
+	timing.tcpConnect = time.Since(start)
+	if dc, ok := conn.(*dialedConn); ok {
+		timing.dnsLookup = dc.dnsLookup
+		timing.tcpConnect = dc.tcpConnect
+		conn = dc.Conn
+	}
+
+	// 自定义的 Dial 可能已经完成了 TLS 握手
+	_, handshaked := conn.(interface{ Handshake() error })
+	if bandwidth != nil {
+		// 在 TLS 之下包装，统计与限制的是线路上的实际流量
+		conn = bandwidth.wrap(host, conn)
+	}
+	if !isTLS || handshaked {
+		return cli.traceConn(conn, timing), timing, nil
+	}
+
+	config := clientTLSConfig(tlsConfig, addr, sni)
+	if nextProtos != nil {
+		config.NextProtos = nextProtos
+	}
+	tlsConn := tls.Client(conn, config)
+	if handshakeTimeout > 0 {
+		_ = tlsConn.SetDeadline(time.Now().Add(handshakeTimeout))
+	}
+	handshakeStart := time.Now()
+	if err = tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, timing, fasthttp.ErrTLSHandshakeTimeout
+		}
+		return nil, timing, err
+	}
+	timing.tlsHandshake = time.Since(handshakeStart)
+	_ = tlsConn.SetDeadline(time.Time{})
+
+	return cli.traceConn(tlsConn, timing), timing, nil
+}
+
+// traceConn 包装连接以记录每次交互的耗时与明文，net/http 仅对 *tls.Conn 启用 HTTP/2，因此协商为 HTTP/2 的连接不做包装
+func (cli *Client) traceConn(conn net.Conn, timing connTiming) net.Conn {
+	if tc, ok := conn.(tlsStateConn); ok && tc.ConnectionState().NegotiatedProtocol == "h2" {
+		return conn
+	}
+	return newTraceConn(conn, timing, cli.wireDumpEnabled)
+}
+
+// clientTLSConfig 复制 TLS 配置并补全 SNI，与 fasthttp 的处理方式保持一致，sni 不为空时优先使用
+func clientTLSConfig(c *tls.Config, addr, sni string) *tls.Config {
+	if c == nil {
+		c = &tls.Config{}
+	} else {
+		c = c.Clone()
+	}
+
+	if sni != "" {
+		c.ServerName = sni
+		return c
+	}
+
+	if c.ServerName == "" {
+		host := addr
+		if strings.Contains(addr, ":") {
+			h, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				c.InsecureSkipVerify = true
+				return c
+			}
+			host = h
+		}
+		c.ServerName = host
+	}
+	return c
+}
@@ -0,0 +1,183 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// staticResolver 返回固定解析结果的解析器
+type staticResolver []string
+
+func (r staticResolver) LookupIPAddr(_ context.Context, _ string) ([]net.IPAddr, error) {
+	addrs := make([]net.IPAddr, 0, len(r))
+	for _, ip := range r {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestClientLocalAddr(t *testing.T) {
+	var mu sync.Mutex
+	sources := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		mu.Lock()
+		sources[host]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient().SetLocalAddr("127.0.0.1", "127.0.0.2", "::1")
+	for i := 0; i < 4; i++ {
+		// 每次请求都建立新连接
+		if _, err := client.R().SetHeader("Connection", "close").Get(server.URL + "/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// IPv4 目标只使用 IPv4 源地址，并轮流使用
+	if sources["127.0.0.1"] != 2 || sources["127.0.0.2"] != 2 {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+
+	client = NewClient().SetLocalAddr("::1")
+	if _, err := client.R().Get(server.URL + "/"); err == nil {
+		t.Fatal("expected error without local address of the same family")
+	}
+}
+
+func TestDialerIPFamily(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	addr := "dual.local:" + port
+
+	tests := []struct {
+		family IPFamily
+		ok     bool
+	}{
+		{IPFamilyDefault, true},
+		{IPv4Only, true},
+		{IPv6Only, false},
+		{PreferIPv4, true},
+		{HappyEyeballs, true},
+	}
+	for _, test := range tests {
+		dialer := NewDialer().SetResolver(staticResolver{"::1", "127.0.0.1"}).SetIPFamily(test.family)
+		dialer.Timeout = time.Second
+		conn, err := dialer.Dial(addr)
+		if (err == nil) != test.ok {
+			t.Fatalf("family %d: unexpected result %v", test.family, err)
+		}
+		if err == nil {
+			if remote := conn.RemoteAddr().String(); remote != listener.Addr().String() {
+				t.Fatalf("family %d: unexpected remote %s", test.family, remote)
+			}
+			_ = conn.Close()
+		}
+	}
+}
+
+func TestDialerIPFamilyStatic(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	// IP 地址与静态解析记录同样按协议族过滤
+	tests := []struct {
+		family IPFamily
+		addr   string
+		ok     bool
+	}{
+		{IPv4Only, "127.0.0.1:" + port, true},
+		{IPv6Only, "127.0.0.1:" + port, false},
+		{IPv4Only, "[::1]:" + port, false},
+		{IPv4Only, "v4.local:" + port, true},
+		{IPv6Only, "v4.local:" + port, false},
+		{IPv4Only, "v6.local:" + port, false},
+	}
+	for _, test := range tests {
+		dialer := NewDialer().SetIPFamily(test.family).
+			SetHostOverride("v4.local", "127.0.0.1").
+			SetHostOverride("v6.local", "::1")
+		dialer.Timeout = time.Second
+		conn, err := dialer.Dial(test.addr)
+		if (err == nil) != test.ok {
+			t.Fatalf("family %d, %s: unexpected result %v", test.family, test.addr, err)
+		}
+		var dnsErr *net.DNSError
+		if err != nil && !errors.As(err, &dnsErr) {
+			t.Fatalf("family %d, %s: expected dns error, got %v", test.family, test.addr, err)
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+func TestSortIPs(t *testing.T) {
+	var ips []net.IP
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "::1", "::2", "::3"} {
+		ips = append(ips, net.ParseIP(ip))
+	}
+	tests := map[IPFamily]string{
+		IPFamilyDefault: "[1.1.1.1 2.2.2.2 ::1 ::2 ::3]",
+		IPv4Only:        "[1.1.1.1 2.2.2.2]",
+		IPv6Only:        "[::1 ::2 ::3]",
+		PreferIPv4:      "[1.1.1.1 2.2.2.2 ::1 ::2 ::3]",
+		HappyEyeballs:   "[::1 1.1.1.1 ::2 2.2.2.2 ::3]",
+	}
+	for family, expected := range tests {
+		if actual := fmt.Sprint(sortIPs(ips, family)); actual != expected {
+			t.Errorf("family %d: expected %s, got %s", family, expected, actual)
+		}
+	}
+}
+
+func TestDialRace(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	dialer := NewDialer()
+	dialer.init()
+	// 第一个地址连接失败后立即尝试下一个，而不是等待 HappyEyeballsDelay
+	start := time.Now()
+	conn, err := dialer.dialRace([]net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.1")}, "1", time.Now().Add(time.Second))
+	if err == nil {
+		_ = conn.Close()
+		t.Fatal("expected connection refused")
+	}
+	conn, err = dialer.dialRace([]net.IP{net.ParseIP("::1"), net.ParseIP("127.0.0.1")}, port, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if elapsed := time.Since(start); elapsed >= HappyEyeballsDelay {
+		t.Fatalf("dial race waited %s after failure", elapsed)
+	}
+}
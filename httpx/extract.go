@@ -0,0 +1,312 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExtractorType 提取器类型
+type ExtractorType string
+
+const (
+	ExtractRegex  ExtractorType = "regex"  // 正则表达式，支持捕获组
+	ExtractJSON   ExtractorType = "json"   // JSONPath，如 $.data.items[0].id
+	ExtractXPath  ExtractorType = "xpath"  // XPath，Content-Type 包含 xml 时按 XML 解析，否则按 HTML 解析
+	ExtractCSS    ExtractorType = "css"    // CSS 选择器
+	ExtractHeader ExtractorType = "header" // 响应头，忽略大小写
+	ExtractCookie ExtractorType = "cookie" // Set-Cookie 中的 Cookie 值
+)
+
+// Extractor 响应数据提取器
+type Extractor struct {
+	Name  string        // 提取结果的名称
+	Type  ExtractorType // 提取器类型
+	Expr  string        // 正则表达式、JSONPath、XPath、CSS 选择器、响应头名称或 Cookie 名称
+	Group int           // 正则表达式的捕获组序号，默认为 0 即完整匹配
+	Attr  string        // CSS 选择器提取的属性名称，为空时提取文本
+
+	pattern string         // 创建时的正则表达式，Expr 被修改后不再使用 re、reErr
+	re      *regexp.Regexp // 创建时编译的正则表达式
+	reErr   error
+}
+
+// RegexExtractor 创建正则表达式提取器，group 为捕获组序号，正则表达式在创建时编译，无效时在提取时返回错误
+func RegexExtractor(name, pattern string, group int) *Extractor {
+	e := &Extractor{Name: name, Type: ExtractRegex, Expr: pattern, Group: group, pattern: pattern}
+	e.re, e.reErr = regexp.Compile(pattern)
+	return e
+}
+
+// JSONExtractor 创建 JSONPath 提取器
+func JSONExtractor(name, path string) *Extractor {
+	return &Extractor{Name: name, Type: ExtractJSON, Expr: path}
+}
+
+// XPathExtractor 创建 XPath 提取器，如 //input[@name="csrf"]/@value
+func XPathExtractor(name, path string) *Extractor {
+	return &Extractor{Name: name, Type: ExtractXPath, Expr: path}
+}
+
+// CSSExtractor 创建 CSS 选择器提取器，attr 为空时提取文本
+func CSSExtractor(name, selector, attr string) *Extractor {
+	return &Extractor{Name: name, Type: ExtractCSS, Expr: selector, Attr: attr}
+}
+
+// HeaderExtractor 创建响应头提取器
+func HeaderExtractor(name, header string) *Extractor {
+	return &Extractor{Name: name, Type: ExtractHeader, Expr: header}
+}
+
+// CookieExtractor 创建 Cookie 提取器
+func CookieExtractor(name, cookie string) *Extractor {
+	return &Extractor{Name: name, Type: ExtractCookie, Expr: cookie}
+}
+
+// ExtractResult 单个提取器的提取结果
+type ExtractResult struct {
+	Name   string   // 提取结果的名称
+	Values []string // 所有提取到的值，JSON 中的对象与数组会序列化为 JSON 字符串
+	Raw    []any    // JSONPath 提取到的原始值，其余提取器与 Values 一致
+}
+
+// Exists 是否提取到了值
+func (r *ExtractResult) Exists() bool {
+	return r != nil && len(r.Values) > 0
+}
+
+// String 获取第一个值，没有提取到值时返回空字符串
+func (r *ExtractResult) String() string {
+	if !r.Exists() {
+		return ""
+	}
+	return r.Values[0]
+}
+
+// Strings 获取所有值
+func (r *ExtractResult) Strings() []string {
+	if r == nil {
+		return nil
+	}
+	return r.Values
+}
+
+// Int 将第一个值转换为整数
+func (r *ExtractResult) Int() (int, error) {
+	return strconv.Atoi(strings.TrimSpace(r.String()))
+}
+
+// Float 将第一个值转换为浮点数
+func (r *ExtractResult) Float() (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(r.String()), 64)
+}
+
+// Bool 将第一个值转换为布尔值
+func (r *ExtractResult) Bool() (bool, error) {
+	return strconv.ParseBool(strings.TrimSpace(r.String()))
+}
+
+// Extracted 多个提取器的提取结果，key 为提取结果的名称
+type Extracted map[string]*ExtractResult
+
+// Get 获取提取结果，不存在时返回 nil，nil 结果的方法仍然可以安全调用
+func (e Extracted) Get(name string) *ExtractResult {
+	return e[name]
+}
+
+// Map 获取每个提取结果的第一个值，可直接用于下一个请求的
+// SetHeaders、SetQueryParams、SetFormDatas、SetPathParams 等方法
+func (e Extracted) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for name, result := range e {
+		if result.Exists() {
+			m[name] = result.String()
+		}
+	}
+	return m
+}
+
+// Extract 使用提取器从响应中提取数据，任意提取器无效或解析响应失败时返回错误，未提取到值不视为错误
+func (r *Response) Extract(extractors ...*Extractor) (Extracted, error) {
+	extracted := make(Extracted, len(extractors))
+	for _, e := range extractors {
+		result, err := e.Extract(r)
+		if err != nil {
+			return nil, err
+		}
+		extracted[e.Name] = result
+	}
+	return extracted, nil
+}
+
+// Extract 从响应中提取数据
+func (e *Extractor) Extract(resp *Response) (*ExtractResult, error) {
+	result := &ExtractResult{Name: e.Name}
+	var err error
+	switch e.Type {
+	case ExtractRegex:
+		err = e.extractRegex(resp, result)
+	case ExtractJSON:
+		err = e.extractJSON(resp, result)
+	case ExtractXPath:
+		err = e.extractXPath(resp, result)
+	case ExtractCSS:
+		err = e.extractCSS(resp, result)
+	case ExtractHeader:
+		for key, value := range resp.OriginalResponse.Header.All() {
+			if strings.EqualFold(string(key), e.Expr) {
+				result.add(string(value))
+			}
+		}
+	case ExtractCookie:
+		e.extractCookie(resp, result)
+	default:
+		err = fmt.Errorf("unsupported extractor type: %s", e.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("extract %s failed: %v", e.Name, err)
+	}
+	return result, nil
+}
+
+// regexp 获取正则表达式，未通过 `RegexExtractor` 创建时在每次提取时编译
+func (e *Extractor) regexp() (*regexp.Regexp, error) {
+	if (e.re != nil || e.reErr != nil) && e.pattern == e.Expr {
+		return e.re, e.reErr
+	}
+	return regexp.Compile(e.Expr)
+}
+
+func (e *Extractor) extractRegex(resp *Response, result *ExtractResult) error {
+	re, err := e.regexp()
+	if err != nil {
+		return err
+	}
+	if e.Group < 0 || e.Group > re.NumSubexp() {
+		return fmt.Errorf("group %d out of range", e.Group)
+	}
+	for _, match := range re.FindAllSubmatch(resp.Body(), -1) {
+		result.add(string(match[e.Group]))
+	}
+	return nil
+}
+
+func (e *Extractor) extractJSON(resp *Response, result *ExtractResult) error {
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("decode json: %v", err)
+	}
+
+	values, err := evalJSONPath(doc, e.Expr)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case nil:
+			s = "null"
+		case bool:
+			s = strconv.FormatBool(v)
+		default:
+			b, _ := json.Marshal(v)
+			s = string(b)
+		}
+		result.Values = append(result.Values, s)
+		result.Raw = append(result.Raw, value)
+	}
+	return nil
+}
+
+func (e *Extractor) extractXPath(resp *Response, result *ExtractResult) error {
+	contentType := strings.ToLower(string(resp.OriginalResponse.Header.ContentType()))
+	if strings.Contains(contentType, "xml") && !strings.Contains(contentType, "html") {
+		doc, err := xmlquery.Parse(bytes.NewReader(resp.Body()))
+		if err != nil {
+			return fmt.Errorf("parse xml: %v", err)
+		}
+		nodes, err := xmlquery.QueryAll(doc, e.Expr)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			result.add(strings.TrimSpace(node.InnerText()))
+		}
+		return nil
+	}
+
+	doc, err := htmlquery.Parse(bytes.NewReader(resp.Body()))
+	if err != nil {
+		return fmt.Errorf("parse html: %v", err)
+	}
+	nodes, err := htmlquery.QueryAll(doc, e.Expr)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		result.add(strings.TrimSpace(htmlquery.InnerText(node)))
+	}
+	return nil
+}
+
+// extractCSS 与 XPath 使用相同的 HTML 解析结果，通过 cascadia 匹配节点
+func (e *Extractor) extractCSS(resp *Response, result *ExtractResult) error {
+	selector, err := cascadia.Compile(e.Expr)
+	if err != nil {
+		return fmt.Errorf("invalid css selector %s: %v", e.Expr, err)
+	}
+	doc, err := htmlquery.Parse(bytes.NewReader(resp.Body()))
+	if err != nil {
+		return fmt.Errorf("parse html: %v", err)
+	}
+	for _, node := range cascadia.QueryAll(doc, selector) {
+		if e.Attr == "" {
+			result.add(strings.TrimSpace(htmlquery.InnerText(node)))
+		} else if value, ok := htmlAttr(node, e.Attr); ok {
+			result.add(value)
+		}
+	}
+	return nil
+}
+
+// htmlAttr 获取节点的属性值
+func htmlAttr(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && strings.EqualFold(attr.Key, name) {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+func (e *Extractor) extractCookie(resp *Response, result *ExtractResult) {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	for key, value := range resp.OriginalResponse.Header.All() {
+		if !strings.EqualFold(string(key), "Set-Cookie") {
+			continue
+		}
+		if err := cookie.ParseBytes(value); err == nil && string(cookie.Key()) == e.Expr {
+			result.add(string(cookie.Value()))
+		}
+	}
+}
+
+func (r *ExtractResult) add(value string) {
+	r.Values = append(r.Values, value)
+	r.Raw = append(r.Raw, value)
+}
@@ -0,0 +1,124 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseExtract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data": {"items": [{"id": 7, "tags": ["a"]}, {"id": 8, "tags": []}]}, "ok": true}`))
+		case "/xml":
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<config><version>2.4.1</version></config>`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Add("Set-Cookie", "sid=abc123; Path=/; HttpOnly")
+			w.Header().Set("X-Version", "nginx/1.25")
+			_, _ = w.Write([]byte(`<html><head><title> Login </title></head><body>
+<form><input type="hidden" name="csrf" value="tok-1"><input name="user"></form>
+<p class="ver">v1.2.3</p></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.R().Get(server.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracted, err := resp.Extract(
+		RegexExtractor("version", `v(\d+)\.(\d+)\.\d+`, 2),
+		XPathExtractor("csrf", `//input[@name="csrf"]/@value`),
+		XPathExtractor("title", `//title`),
+		CSSExtractor("inputs", "form input", "name"),
+		CSSExtractor("ver", "p.ver", ""),
+		HeaderExtractor("server", "x-version"),
+		CookieExtractor("sid", "sid"),
+		CSSExtractor("missing", "#missing", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"version": "2", "csrf": "tok-1", "title": "Login", "inputs": "csrf",
+		"ver": "v1.2.3", "server": "nginx/1.25", "sid": "abc123",
+	}
+	for name, want := range expected {
+		if got := extracted.Get(name).String(); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if len(extracted.Get("inputs").Strings()) != 2 || extracted.Get("missing").Exists() || extracted.Get("unknown").Exists() {
+		t.Error("unexpected extracted values")
+	}
+	if n, err := extracted.Get("version").Int(); err != nil || n != 2 {
+		t.Errorf("expected int 2, got %d %v", n, err)
+	}
+
+	// 提取结果用于下一个请求
+	if _, ok := extracted.Map()["missing"]; ok {
+		t.Error("missing value should not be in map")
+	}
+
+	resp, err = client.R().Get(server.URL + "/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracted, err = resp.Extract(JSONExtractor("ids", "$.data.items[*].id"), JSONExtractor("ok", "ok"),
+		JSONExtractor("first", "$..items[0]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := extracted.Get("ids").Strings(); len(ids) != 2 || ids[0] != "7" || ids[1] != "8" {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+	if ok, err := extracted.Get("ok").Bool(); err != nil || !ok {
+		t.Errorf("expected ok true, got %t %v", ok, err)
+	}
+	if first := extracted.Get("first"); first.String() != `{"id":7,"tags":["a"]}` {
+		t.Errorf("unexpected first: %s", first.String())
+	}
+
+	resp, err = client.R().Get(server.URL + "/xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracted, err = resp.Extract(XPathExtractor("version", "/config/version"))
+	if err != nil || extracted.Get("version").String() != "2.4.1" {
+		t.Errorf("unexpected xml version: %v %v", extracted.Get("version").Strings(), err)
+	}
+
+	// 正则表达式在创建时编译，修改 Expr 后重新编译
+	e := RegexExtractor("version", `\d+`, 0)
+	if e.re == nil {
+		t.Fatal("regex should be compiled when the extractor is built")
+	}
+	e.Expr = `\d+\.\d+`
+	if result, err := e.Extract(resp); err != nil || result.String() != "2.4" {
+		t.Errorf("unexpected regex result: %v %v", result.Strings(), err)
+	}
+	// 创建时无效的正则表达式，修改 Expr 后可以正常使用
+	e = RegexExtractor("version", "(", 0)
+	e.Expr = `\d+\.\d+`
+	if result, err := e.Extract(resp); err != nil || result.String() != "2.4" {
+		t.Errorf("unexpected regex result after fixing expr: %v %v", result.Strings(), err)
+	}
+
+	for _, e := range []*Extractor{
+		RegexExtractor("bad", "(", 0),
+		RegexExtractor("group", "a", 1),
+		CSSExtractor("bad", "[[", ""),
+		XPathExtractor("bad", "//["),
+		JSONExtractor("bad", "$.a"),
+		{Name: "bad", Type: "unknown"},
+	} {
+		if _, err = resp.Extract(e); err == nil {
+			t.Errorf("%s %s: expected error", e.Type, e.Expr)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HttpTransport 基于 `net/http` 的传输层，通过 ALPN 协商支持 HTTP/2，
+// 连接仍然通过 `Client` 的 Dial/TLSConfig 建立，因此代理、DNS 配置同样生效。
+// 底层连接池根据 `Client` 的配置创建，配置变化时重新创建，每个 `Client` 应使用单独的 HttpTransport
+type HttpTransport struct {
+	DisableHTTP2 bool // 禁用 HTTP/2，只使用 HTTP/1.1
+
+	transports map[string]*http.Transport // 按连接路由区分的连接池
+	config     transportConfig            // 创建连接池时的配置
+	mu         sync.Mutex
+}
+
+// maxTransports 按连接路由缓存的连接池数量上限，超过时淘汰其中一个
+const maxTransports = 64
+
+// transportConfig 创建连接池时使用的配置，任意一项变化时关闭并重新创建所有连接池
+type transportConfig struct {
+	disableHTTP2    bool
+	tlsConfig       *tls.Config
+	maxConnsPerHost int
+	idleConnTimeout time.Duration
+	readTimeout     time.Duration
+	readBufferSize  int
+	writeBufferSize int
+}
+
+// NewHttpTransport 创建基于 `net/http` 的传输层
+func NewHttpTransport() *HttpTransport {
+	return &HttpTransport{}
+}
+
+// httpTraceKey 用于在 context 中传递 httpTrace
+type httpTraceKey struct{}
+
+// httpTrace 记录 `net/http` 请求各阶段的时间
+type httpTrace struct {
+	mu         sync.Mutex
+	conn       connTiming
+	dialed     bool
+	reused     bool
+	idle       time.Duration
+	remoteAddr net.Addr
+	localAddr  net.Addr
+	wroteDone  time.Time
+	firstByte  time.Time
+}
+
+func (t *HttpTransport) RoundTrip(cli *Client, req *fasthttp.Request, resp *fasthttp.Response) (*Timing, error) {
+	cli.clock.Lock()
+	timeout := cli.ReadTimeout + cli.WriteTimeout
+	maxBodySize := cli.MaxResponseBodySize
+	req.URI().DisablePathNormalizing = cli.DisablePathNormalizing
+	cli.clock.Unlock()
+
+	host := string(req.URI().Host())
+	target, sni, connect, routed := parseRouteAddr(host)
+	if routed {
+		host = string(req.Header.Host())
+	}
+	transport := t.transport(cli, target, sni, connect, routed)
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	trace := &httpTrace{}
+	ctx = context.WithValue(ctx, httpTraceKey{}, trace)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.mu.Lock()
+			defer trace.mu.Unlock()
+			trace.reused = info.Reused
+			trace.idle = info.IdleTime
+			trace.remoteAddr = info.Conn.RemoteAddr()
+			trace.localAddr = info.Conn.LocalAddr()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			trace.mu.Lock()
+			defer trace.mu.Unlock()
+			trace.wroteDone = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			trace.mu.Lock()
+			defer trace.mu.Unlock()
+			trace.firstByte = time.Now()
+		},
+	})
+
+	httpReq, err := toHttpRequest(ctx, req, host, cli.NoDefaultUserAgentHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	httpResp, err := transport.RoundTrip(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if err = fromHttpResponse(httpResp, trace.headerOrder(), resp, req.Header.IsHead(), maxBodySize); err != nil {
+		return nil, err
+	}
+	timing := trace.timing(start, time.Now())
+	timing.tlsState = httpResp.TLS
+	if timing.wire != nil {
+		cli.writeWireDump(timing.wire)
+	}
+	return timing, nil
+}
+
+// transport 获取连接路由对应的连接池，不同路由的连接地址、SNI 不同，不能共用连接
+func (t *HttpTransport) transport(cli *Client, target, sni, connect string, routed bool) *http.Transport {
+	key := ""
+	if routed {
+		key = target + "@" + sni + "@" + connect
+	}
+
+	cli.clock.Lock()
+	config := transportConfig{
+		disableHTTP2:    t.DisableHTTP2,
+		tlsConfig:       cli.TLSConfig,
+		maxConnsPerHost: cli.MaxConnsPerHost,
+		idleConnTimeout: cli.MaxIdleConnDuration,
+		readTimeout:     cli.ReadTimeout,
+		readBufferSize:  cli.ReadBufferSize,
+		writeBufferSize: cli.WriteBufferSize,
+	}
+	cli.clock.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transports == nil || t.config != config {
+		// 已建立的连接仍在使用旧配置，关闭后由新的连接池重新建立
+		t.closeIdleConnections()
+		t.transports = make(map[string]*http.Transport)
+		t.config = config
+	}
+	if transport, ok := t.transports[key]; ok {
+		return transport
+	}
+	if len(t.transports) >= maxTransports {
+		for k, transport := range t.transports {
+			transport.CloseIdleConnections()
+			delete(t.transports, k)
+			break
+		}
+	}
+
+	nextProtos := []string{"h2", "http/1.1"}
+	if config.disableHTTP2 {
+		nextProtos = []string{"http/1.1"}
+	}
+
+	dial := func(ctx context.Context, addr string, isTLS bool) (net.Conn, error) {
+		host := addr
+		if routed {
+			host, addr = target, connect
+		}
+		conn, timing, err := cli.dialConn(host, addr, isTLS, false, sni, nextProtos)
+		if err != nil {
+			return nil, err
+		}
+		if trace, ok := ctx.Value(httpTraceKey{}).(*httpTrace); ok {
+			trace.mu.Lock()
+			trace.conn = timing
+			trace.dialed = true
+			trace.mu.Unlock()
+		}
+		return conn, nil
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, addr, false)
+		},
+		DialTLSContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, addr, true)
+		},
+		ForceAttemptHTTP2:     !config.disableHTTP2,
+		DisableCompression:    true,
+		MaxConnsPerHost:       config.maxConnsPerHost,
+		MaxIdleConnsPerHost:   config.maxConnsPerHost,
+		IdleConnTimeout:       config.idleConnTimeout,
+		ResponseHeaderTimeout: config.readTimeout,
+		ReadBufferSize:        config.readBufferSize,
+		WriteBufferSize:       config.writeBufferSize,
+	}
+
+	if config.disableHTTP2 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	t.transports[key] = transport
+	return transport
+}
+
+// CloseIdleConnections 关闭所有空闲连接
+func (t *HttpTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeIdleConnections()
+}
+
+func (t *HttpTransport) closeIdleConnections() {
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// timing 生成耗时统计
+func (t *httpTrace) timing(start, end time.Time) *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := &Timing{
+		Total:        end.Sub(start),
+		ConnReused:   t.reused,
+		ConnIdleTime: t.idle,
+	}
+	if t.dialed && !t.reused {
+		timing.DNSLookup = t.conn.dnsLookup
+		timing.TCPConnect = t.conn.tcpConnect
+		timing.TLSHandshake = t.conn.tlsHandshake
+	}
+	if t.remoteAddr != nil {
+		timing.RemoteAddr = t.remoteAddr.String()
+	}
+	if ta, ok := t.remoteAddr.(*traceAddr); ok {
+		// HTTP/1.1 连接上每次取出连接时调用 RemoteAddr 开始新的交互
+		timing.wire = ta.exchange.wire.bytes()
+	}
+	if t.localAddr != nil {
+		timing.LocalAddr = t.localAddr.String()
+	}
+	if !t.firstByte.IsZero() {
+		timing.TimeToFirstByte = t.firstByte.Sub(start)
+		timing.BodyRead = end.Sub(t.firstByte)
+		if !t.wroteDone.IsZero() {
+			timing.ServerProcessing = t.firstByte.Sub(t.wroteDone)
+		}
+	}
+	return timing
+}
+
+// headerOrder 获取 HTTP/1.x 连接上响应头的接收顺序，HTTP/2 连接无法记录，返回 nil
+func (t *httpTrace) headerOrder() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ta, ok := t.remoteAddr.(*traceAddr); ok {
+		return ta.exchange.header.order()
+	}
+	return nil
+}
+
+// toHttpRequest 将 `fasthttp.Request` 转换为 `http.Request`，请求路径保持原样发送
+func toHttpRequest(ctx context.Context, req *fasthttp.Request, host string, noDefaultUserAgent bool) (*http.Request, error) {
+	uri := req.URI()
+	requestURI := string(uri.RequestURI())
+	path, query, _ := strings.Cut(requestURI, "?")
+
+	u := &url.URL{Scheme: string(uri.Scheme()), Host: host, Opaque: path, RawQuery: query}
+	if strings.HasPrefix(path, "//") {
+		// 以 // 开头的 Opaque 会被当作绝对地址发送
+		u.Opaque = ""
+		u.Path = path
+	}
+
+	body := req.Body()
+	httpReq, err := http.NewRequestWithContext(ctx, string(req.Header.Method()), u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create http request failed: %v", err)
+	}
+	httpReq.URL = u
+	httpReq.Host = host
+	httpReq.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		httpReq.Body = http.NoBody
+	}
+
+	for key, value := range req.Header.All() {
+		k := string(key)
+		switch strings.ToLower(k) {
+		case "host", "content-length", "transfer-encoding":
+		case "connection":
+			httpReq.Close = strings.EqualFold(string(value), "close")
+		case "user-agent", "content-type":
+			// 有序请求头模式下 `All` 会重复返回这两个请求头
+			httpReq.Header[http.CanonicalHeaderKey(k)] = []string{string(value)}
+		default:
+			httpReq.Header[k] = append(httpReq.Header[k], string(value))
+		}
+	}
+
+	if _, ok := httpReq.Header["User-Agent"]; !ok && noDefaultUserAgent {
+		// 空值表示不发送 User-Agent，与 fasthttp 的行为保持一致
+		httpReq.Header["User-Agent"] = []string{""}
+	}
+	return httpReq, nil
+}
+
+// fromHttpResponse 将 `http.Response` 转换为 `fasthttp.Response`，并记录实际使用的协议。
+// 保留服务器返回的状态描述，响应头按 order 中的接收顺序排列，其余（如 HTTP/2 的响应头）按名称排序
+func fromHttpResponse(httpResp *http.Response, order []string, resp *fasthttp.Response, isHead bool, maxBodySize int) error {
+	var body []byte
+	if !isHead {
+		reader := io.Reader(httpResp.Body)
+		if maxBodySize > 0 {
+			reader = io.LimitReader(httpResp.Body, int64(maxBodySize)+1)
+		}
+		var err error
+		if body, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("read response body failed: %v", err)
+		}
+		if maxBodySize > 0 && len(body) > maxBodySize {
+			return fasthttp.ErrBodyTooLarge
+		}
+	}
+
+	var buf bytes.Buffer
+	status := httpResp.Status
+	if !strings.HasPrefix(status, strconv.Itoa(httpResp.StatusCode)) {
+		status = fmt.Sprintf("%d %s", httpResp.StatusCode, http.StatusText(httpResp.StatusCode))
+	}
+	fmt.Fprintf(&buf, "HTTP/1.1 %s\r\n", status)
+
+	written := make(map[string]bool, len(httpResp.Header))
+	writeHeader := func(name string) {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		lower := strings.ToLower(key)
+		if written[key] || lower == "transfer-encoding" || (lower == "content-length" && !isHead) {
+			return
+		}
+		written[key] = true
+		for _, value := range httpResp.Header[key] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	for _, name := range order {
+		writeHeader(name)
+	}
+	keys := make([]string, 0, len(httpResp.Header))
+	for key := range httpResp.Header {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeHeader(key)
+	}
+	if !isHead {
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n", len(body))
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+
+	resp.SkipBody = isHead
+	if err := resp.Read(bufio.NewReader(&buf)); err != nil {
+		return fmt.Errorf("convert response failed: %v", err)
+	}
+	resp.Header.SetProtocol([]byte(httpResp.Proto))
+	return nil
+}
@@ -0,0 +1,132 @@
+package httpx
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHttpTransport(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Proto", r.Proto)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		_, _ = w.Write([]byte(r.Method + " " + r.Host + " " + r.URL.RequestURI() + " " + string(body)))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewClient().SetTransport(NewHttpTransport())
+	resp, err := client.R().SetQueryParam("a", "1").SetBodyString("hello").Post(server.URL + "/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Proto() != "HTTP/2.0" || resp.Header().Get("X-Proto") != "HTTP/2.0" {
+		t.Fatalf("unexpected protocol: %s", resp.Proto())
+	}
+	u, _ := url.Parse(server.URL)
+	if expected := "POST " + u.Host + " /path?a=1 hello"; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+	t.Log(resp.Timing())
+	t.Log(resp)
+
+	resp, err = client.R().SetConnectAddress(u.Host).Get("https://a.example.com:" + u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "GET a.example.com:" + u.Port() + " / "; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+
+	transport := NewHttpTransport()
+	transport.DisableHTTP2 = true
+	resp, err = NewClient().SetTransport(transport).R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Proto() != "HTTP/1.1" {
+		t.Fatalf("unexpected protocol: %s", resp.Proto())
+	}
+
+	resp, err = NewClient().R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Proto() != "HTTP/1.1" {
+		t.Fatalf("unexpected protocol: %s", resp.Proto())
+	}
+}
+
+func TestHttpTransportConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := NewHttpTransport()
+	client := NewClient().SetTransport(transport)
+	if _, err := client.R().Get(server.URL + "/slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改配置后重新创建连接池，新的超时时间生效
+	client.SetReadTimeout(100 * time.Millisecond)
+	if _, err := client.R().Get(server.URL + "/slow"); err == nil {
+		t.Fatal("expected timeout with the new read timeout")
+	}
+
+	// 按连接路由缓存的连接池数量有上限
+	u, _ := url.Parse(server.URL)
+	for i := 0; i < maxTransports+8; i++ {
+		if _, err := client.R().SetConnectAddress(u.Host).Get("http://h" + strconv.Itoa(i) + ".example.com:" + u.Port()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(transport.transports); n > maxTransports {
+		t.Fatalf("expected at most %d transports, got %d", maxTransports, n)
+	}
+}
+
+func TestHttpTransportResponseHead(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err = http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 103 Early Hints\r\nLink: </a.css>\r\n\r\n" +
+			"HTTP/1.1 200 Fine Thanks\r\nZ-Last: 1\r\nSet-Cookie: a=1\r\nA-First: 2\r\nSet-Cookie: b=2\r\nContent-Length: 2\r\n\r\nok"))
+	}()
+
+	// 保留服务器返回的状态描述与响应头的接收顺序
+	resp, err := NewClient().SetTransport(NewHttpTransport()).R().Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := resp.HeaderString()
+	if !strings.HasPrefix(head, "HTTP/1.1 200 Fine Thanks\r\n") ||
+		!strings.Contains(head, "Z-Last: 1\r\nA-First: 2\r\n") || !strings.Contains(head, "Set-Cookie: a=1\r\nSet-Cookie: b=2\r\n") {
+		t.Fatalf("unexpected response head:\n%s", head)
+	}
+}
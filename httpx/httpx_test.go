@@ -1,14 +1,25 @@
-package httpx
+package httpx_test
 
 import (
 	"crypto/tls"
+	"github.com/kelesec/gopkg/httpx"
+	"github.com/kelesec/gopkg/httpx/httpxtest"
 	"github.com/valyala/fasthttp"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestClient(t *testing.T) {
-	client := NewClient().SetReadTimeout(10 * time.Second).
+	server := httpxtest.NewTLSServer()
+	defer server.Close()
+	server.On(httpx.MethodGet, "/").Reply(200, "hello")
+
+	client := httpx.NewClient().SetReadTimeout(10 * time.Second).
 		SetWriteTimeout(10 * time.Second).
 		SetMaxIdleConnDuration(10 * time.Second).
 		SetMaxConnWaitTimeout(10 * time.Second).
@@ -22,27 +33,29 @@ func TestClient(t *testing.T) {
 		SetTLSConfig(&tls.Config{
 			InsecureSkipVerify: true,
 		}).
-		SetDial((&fasthttp.TCPDialer{
-			Concurrency:      4096,
-			DNSCacheDuration: time.Hour,
-		}).Dial)
+		SetDial(server.Dial)
 
-	resp, err := client.R().Get("https://baidu.com")
+	resp, err := client.R().Get(server.URL + "/")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(resp.Status(), resp.respSize)
+	if resp.Status() != 200 || resp.BodyString() != "hello" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+	if req := server.LastRequest(); req.Header("User-Agent") == "" {
+		t.Fatalf("expected default User-Agent:\n%s", req.Raw)
+	}
 }
 
 func TestFastHttpClient(t *testing.T) {
-	readTimeout, _ := time.ParseDuration("500ms")
-	writeTimeout, _ := time.ParseDuration("500ms")
-	maxIdleConnDuration, _ := time.ParseDuration("1m")
+	server := httpxtest.NewTLSServer()
+	defer server.Close()
+	server.On(httpx.MethodGet, "/").Reply(200, "hello")
 
 	fc := fasthttp.Client{
-		ReadTimeout:                   readTimeout,
-		WriteTimeout:                  writeTimeout,
-		MaxIdleConnDuration:           maxIdleConnDuration,
+		ReadTimeout:                   500 * time.Millisecond,
+		WriteTimeout:                  500 * time.Millisecond,
+		MaxIdleConnDuration:           time.Minute,
 		NoDefaultUserAgentHeader:      true,
 		DisableHeaderNamesNormalizing: true,
 		DisablePathNormalizing:        true,
@@ -51,23 +64,26 @@ func TestFastHttpClient(t *testing.T) {
 		TLSConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
-		Dial: (&fasthttp.TCPDialer{
-			Concurrency:      4096,
-			DNSCacheDuration: time.Hour,
-		}).Dial,
+		Dial: server.Dial,
 	}
 
-	client := NewClientWithFastHttp(&fc)
-	resp, err := client.R().Get("https://baidu.com")
+	client := httpx.NewClientWithFastHttp(&fc)
+	resp, err := client.R().Get(server.URL + "/")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(resp.Status(), resp.respSize)
+	if resp.Status() != 200 || resp.BodyString() != "hello" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
 }
 
 func TestRequest(t *testing.T) {
-	req := NewClient().R()
-	resp, err := req.SetMethod(MethodGet).
+	server := httpxtest.NewServer()
+	defer server.Close()
+	server.On(httpx.MethodPost, "/").Reply(200, "ok")
+
+	body := `{"username": "admin", "password": "123123"}`
+	resp, err := server.Client().R().SetMethod(httpx.MethodPost).
 		SetHeaders(map[string]string{
 			"header_2": "value_2",
 			"header_3": "value_3",
@@ -78,54 +94,119 @@ func TestRequest(t *testing.T) {
 			"cookie_3": "value_3",
 		}).
 		SetCookie("cookie_1", "value_1").
-		SetContentType(MIMEApplicationJSON).
-		SetContentLength(2048).
-		SetUserAgent(DefaultUserAgent).
+		SetContentType(httpx.MIMEApplicationJSON).
+		SetUserAgent(httpx.DefaultUserAgent).
 		SetQueryParams(map[string]string{
 			"query_2": "value_2",
 			"query_3": "value_3",
 		}).SetQueryParam("query_1", "value_1").
-		//SetFormDatas(map[string]string{
-		//	"form_2": "value_2",
-		//	"form_3": "value_3",
-		//}).SetFormData("form_1", "value_1").
-		SetBodyString(`{"username": "admin", "password": "123123"}`).
+		SetBodyString(body).
 		SetBasicAuth("username", "password").
-		Do("https://baidu.com", "")
-
+		Do(server.URL+"/", "")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(req)
-	t.Log(resp)
+	if resp.Status() != 200 {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	req := server.LastRequest()
+	if req.Method != httpx.MethodPost || string(req.Body) != body {
+		t.Fatalf("unexpected request:\n%s", req.Raw)
+	}
+	// 请求头名称会被规范化
+	for _, key := range []string{"Header_1", "Header_2", "Header_3"} {
+		if req.Header(key) != "value_"+key[len(key)-1:] {
+			t.Fatalf("header %s not sent:\n%s", key, req.Raw)
+		}
+	}
+	for _, key := range []string{"query_1", "query_2", "query_3"} {
+		if req.Query(key) != "value_"+key[len(key)-1:] {
+			t.Fatalf("query %s not sent:\n%s", key, req.Raw)
+		}
+	}
+	for _, cookie := range []string{"cookie_1=value_1", "cookie_2=value_2", "cookie_3=value_3"} {
+		if !strings.Contains(req.Header("Cookie"), cookie) {
+			t.Fatalf("cookie %s not sent:\n%s", cookie, req.Raw)
+		}
+	}
+	if req.Header("Content-Type") != httpx.MIMEApplicationJSON || req.Header("User-Agent") != httpx.DefaultUserAgent ||
+		!strings.HasSuffix(req.Header("Authorization"), "dXNlcm5hbWU6cGFzc3dvcmQ=") {
+		t.Fatalf("unexpected request:\n%s", req.Raw)
+	}
 }
 
 func TestProxy(t *testing.T) {
-	req := NewClient().SetProxy("http://8.147.118.237:18920").R()
-	//req := NewClient().SetProxies([]string{
-	//	"socks5://127.0.0.1:8083",
-	//	"http://127.0.0.1:8083",
-	//}).R()
-	resp, err := req.Get("https://www.baidu.com")
+	server := httpxtest.NewTLSServer()
+	defer server.Close()
+	server.On(httpx.MethodGet, "/").Reply(200, "hello")
+
+	// HTTP CONNECT 代理，隧道连接到测试服务器
+	var tunnels atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := server.Dial(r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = target.Close()
+			return
+		}
+		tunnels.Add(1)
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		_, _ = io.Copy(conn, target)
+		_ = conn.Close()
+	}))
+	defer proxy.Close()
+
+	resp, err := httpx.NewClient().SetProxy(proxy.URL).R().Get(server.URL + "/")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(resp.Status(), resp.respSize)
-	t.Log(resp.BodyString())
+	if resp.BodyString() != "hello" || tunnels.Load() != 1 {
+		t.Fatalf("request should go through proxy: %s, %d tunnels", resp, tunnels.Load())
+	}
 }
 
 func TestRedirect(t *testing.T) {
-	resp, err := NewClient().R().
-		SetUserAgent(ChromeUserAgent).
+	server := httpxtest.NewServer()
+	defer server.Close()
+	server.On(httpx.MethodGet, "/").Redirect(302, "/login")
+	server.On(httpx.MethodGet, "/login").Redirect(301, "/home")
+	server.On(httpx.MethodGet, "/home").Reply(200, "home")
+
+	resp, err := server.Client().R().
+		SetUserAgent(httpx.ChromeUserAgent).
 		AllowRedirect().
 		AllowSaveResponseHistory().
-		Get("http://127.0.0.1:8081")
-
+		Get(server.URL + "/")
 	if err != nil {
 		t.Fatal(err)
 	}
+	if resp.BodyString() != "home" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
 
+	var statuses []int
 	for _, response := range resp.ResponseHistory() {
-		t.Log(response)
+		statuses = append(statuses, response.Status())
+	}
+	if len(statuses) != 3 || statuses[0] != 302 || statuses[1] != 301 || statuses[2] != 200 {
+		t.Fatalf("unexpected response history: %v", statuses)
+	}
+	for _, req := range server.Requests() {
+		if req.Header("User-Agent") != httpx.ChromeUserAgent {
+			t.Fatalf("unexpected User-Agent:\n%s", req.Raw)
+		}
 	}
 }
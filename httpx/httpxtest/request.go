@@ -0,0 +1,49 @@
+package httpxtest
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// Request 测试服务器收到的请求
+type Request struct {
+	Method  string              // 请求方法
+	URI     string              // 请求路径及参数
+	Path    string              // 请求路径
+	Host    string              // Host 请求头
+	Headers map[string][]string // 请求头，保留同名请求头
+	Body    []byte              // 请求体
+	Raw     string              // 完整的请求报文
+}
+
+func newRequest(ctx *fasthttp.RequestCtx) *Request {
+	req := &Request{
+		Method:  string(ctx.Method()),
+		URI:     string(ctx.RequestURI()),
+		Path:    string(ctx.Path()),
+		Host:    string(ctx.Host()),
+		Headers: make(map[string][]string),
+		Body:    append([]byte(nil), ctx.PostBody()...),
+		Raw:     ctx.Request.String(),
+	}
+	for key, value := range ctx.Request.Header.All() {
+		req.Headers[string(key)] = append(req.Headers[string(key)], string(value))
+	}
+	return req
+}
+
+// Header 获取请求头的第一个值
+func (r *Request) Header(key string) string {
+	if values := r.Headers[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Query 获取 URL 请求参数
+func (r *Request) Query(key string) string {
+	var uri fasthttp.URI
+	if err := uri.Parse(nil, []byte(r.URI)); err != nil {
+		return ""
+	}
+	return string(uri.QueryArgs().Peek(key))
+}
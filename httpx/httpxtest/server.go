@@ -0,0 +1,261 @@
+// Package httpxtest 提供基于内存监听器的测试服务器，用于在不访问网络的情况下测试 httpx
+package httpxtest
+
+import (
+	"fmt"
+	"github.com/kelesec/gopkg/httpx"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"net"
+	"sync"
+	"time"
+)
+
+// Host 测试服务器使用的域名，Client 发往任意地址的请求都会连接到测试服务器
+const Host = "httpxtest.local"
+
+// Server 基于 `fasthttputil.InmemoryListener` 的测试服务器，通过 On 编排响应，并记录收到的请求
+type Server struct {
+	URL string // 测试服务器地址，如 http://httpxtest.local
+
+	ln       *fasthttputil.InmemoryListener
+	server   *fasthttp.Server
+	routes   []*Route
+	requests []*Request
+	dialErr  error
+	mu       sync.Mutex
+}
+
+// NewServer 创建并启动 HTTP 测试服务器
+func NewServer() *Server {
+	s := newServer("http")
+	go func() {
+		_ = s.server.Serve(s.ln)
+	}()
+	return s
+}
+
+// NewTLSServer 创建并启动 HTTPS 测试服务器，使用自签名证书
+func NewTLSServer() *Server {
+	cert, key, err := fasthttp.GenerateTestCertificate(Host)
+	if err != nil {
+		panic(fmt.Errorf("generate certificate failed: %s", err))
+	}
+
+	s := newServer("https")
+	go func() {
+		_ = s.server.ServeTLSEmbed(s.ln, cert, key)
+	}()
+	return s
+}
+
+func newServer(schema string) *Server {
+	s := &Server{
+		URL: schema + "://" + Host,
+		ln:  fasthttputil.NewInmemoryListener(),
+	}
+	s.server = &fasthttp.Server{
+		Handler:                       s.handle,
+		DisableHeaderNamesNormalizing: true,
+		NoDefaultServerHeader:         true,
+		NoDefaultDate:                 true,
+		Logger:                        discardLogger{},
+	}
+	return s
+}
+
+// discardLogger 丢弃测试服务器的日志，如模拟连接错误时产生的日志
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...any) {}
+
+// Client 创建连接到测试服务器的 `httpx.Client`
+func (s *Server) Client() *httpx.Client {
+	return httpx.NewClient().SetDial(s.Dial)
+}
+
+// Dial 建立与测试服务器的内存连接，忽略 addr
+func (s *Server) Dial(string) (net.Conn, error) {
+	s.mu.Lock()
+	err := s.dialErr
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.ln.Dial()
+}
+
+// SetDialError 设置建立连接时返回的错误，用于模拟连接失败，传入 nil 时恢复正常
+func (s *Server) SetDialError(err error) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dialErr = err
+	return s
+}
+
+// Close 关闭测试服务器
+func (s *Server) Close() {
+	_ = s.server.Shutdown()
+	_ = s.ln.Close()
+}
+
+// On 添加路由，method 为空时匹配所有请求方法，path 为空时匹配所有路径。
+// 多个路由同时匹配时使用最先添加且未达到次数限制的路由
+func (s *Server) On(method, path string) *Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	route := &Route{
+		method: method,
+		path:   path,
+		status: fasthttp.StatusOK,
+	}
+	s.routes = append(s.routes, route)
+	return route
+}
+
+// Requests 获取收到的所有请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// LastRequest 获取最后收到的请求，没有请求时返回 nil
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// Reset 清空路由与收到的请求
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = nil
+	s.requests = nil
+	s.dialErr = nil
+}
+
+// match 查找匹配的路由，并增加路由的命中次数
+func (s *Server) match(method, path string) *Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, route := range s.routes {
+		if route.match(method, path) {
+			route.hits++
+			return route
+		}
+	}
+	return nil
+}
+
+func (s *Server) handle(ctx *fasthttp.RequestCtx) {
+	req := newRequest(ctx)
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	route := s.match(req.Method, req.Path)
+	if route == nil {
+		ctx.Error(fmt.Sprintf("httpxtest: no route for %s %s", req.Method, req.Path), fasthttp.StatusNotFound)
+		return
+	}
+	route.serve(ctx)
+}
+
+// Route 测试服务器的路由，描述匹配到请求时如何响应
+type Route struct {
+	method  string
+	path    string
+	times   int // 最大命中次数，为 0 时不限制
+	hits    int
+	status  int
+	headers [][2]string
+	body    []byte
+	delay   time.Duration
+	close   bool
+	handler fasthttp.RequestHandler
+}
+
+func (r *Route) match(method, path string) bool {
+	if r.times > 0 && r.hits >= r.times {
+		return false
+	}
+	return (r.method == "" || r.method == method) && (r.path == "" || r.path == path)
+}
+
+// Reply 设置响应状态码与响应体
+func (r *Route) Reply(status int, body string) *Route {
+	r.status = status
+	r.body = []byte(body)
+	return r
+}
+
+// ReplyBytes 设置响应状态码与响应体
+func (r *Route) ReplyBytes(status int, body []byte) *Route {
+	r.status = status
+	r.body = body
+	return r
+}
+
+// Header 添加响应头，允许重复添加同名响应头
+func (r *Route) Header(key, value string) *Route {
+	r.headers = append(r.headers, [2]string{key, value})
+	return r
+}
+
+// Redirect 设置重定向响应
+func (r *Route) Redirect(status int, location string) *Route {
+	r.status = status
+	return r.Header("Location", location)
+}
+
+// Delay 设置响应前的等待时间，用于模拟慢响应与超时
+func (r *Route) Delay(d time.Duration) *Route {
+	r.delay = d
+	return r
+}
+
+// CloseConnection 不返回响应并直接关闭连接，用于模拟连接错误
+func (r *Route) CloseConnection() *Route {
+	r.close = true
+	return r
+}
+
+// Times 设置路由的最大命中次数，达到次数后继续匹配后续路由，可用于编排同一地址的多次响应
+func (r *Route) Times(n int) *Route {
+	r.times = n
+	return r
+}
+
+// Handler 使用自定义的处理函数生成响应，设置后 Reply、Header 等配置不再生效
+func (r *Route) Handler(h fasthttp.RequestHandler) *Route {
+	r.handler = h
+	return r
+}
+
+func (r *Route) serve(ctx *fasthttp.RequestCtx) {
+	if r.delay > 0 {
+		time.Sleep(r.delay)
+	}
+
+	if r.close {
+		ctx.HijackSetNoResponse(true)
+		ctx.Hijack(func(net.Conn) {})
+		return
+	}
+
+	if r.handler != nil {
+		r.handler(ctx)
+		return
+	}
+
+	ctx.SetStatusCode(r.status)
+	for _, header := range r.headers {
+		ctx.Response.Header.Add(header[0], header[1])
+	}
+	ctx.SetBody(r.body)
+}
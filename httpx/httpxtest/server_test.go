@@ -0,0 +1,96 @@
+package httpxtest
+
+import (
+	"errors"
+	"github.com/kelesec/gopkg/httpx"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.On(httpx.MethodGet, "/hello").Reply(200, "hello").Header("X-Test", "1")
+	server.On(httpx.MethodGet, "/redirect").Redirect(302, "/hello")
+	server.On("", "/retry").Reply(500, "error").Times(1)
+	server.On("", "/retry").Reply(200, "ok")
+
+	client := server.Client()
+	resp, err := client.R().SetHeader("X-Req", "1").SetQueryParam("a", "1").Get(server.URL + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != 200 || resp.BodyString() != "hello" || resp.Header().Get("X-Test") != "1" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	req := server.LastRequest()
+	if req.Method != httpx.MethodGet || req.Header("X-Req") != "1" || req.Query("a") != "1" || req.Host != Host {
+		t.Fatalf("unexpected request: %s", req.Raw)
+	}
+
+	resp, err = client.R().AllowRedirect().Get(server.URL + "/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyString() != "hello" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	for _, expected := range []int{500, 200, 200} {
+		resp, err = client.R().Post(server.URL + "/retry")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status() != expected {
+			t.Fatalf("expected %d, got %d", expected, resp.Status())
+		}
+	}
+
+	resp, err = client.R().Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != 404 {
+		t.Fatalf("expected 404, got %d", resp.Status())
+	}
+	if n := len(server.Requests()); n != 7 {
+		t.Fatalf("expected 7 requests, got %d", n)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.On("", "/slow").Delay(200*time.Millisecond).Reply(200, "slow")
+	server.On("", "/close").CloseConnection()
+
+	client := server.Client().SetReadTimeout(50 * time.Millisecond)
+	if _, err := client.R().Get(server.URL + "/slow"); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if _, err := client.R().Get(server.URL + "/close"); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	server.SetDialError(errors.New("connection refused"))
+	if _, err := client.R().Get(server.URL + "/other"); err == nil {
+		t.Fatal("expected dial error")
+	}
+}
+
+func TestTLSServer(t *testing.T) {
+	server := NewTLSServer()
+	defer server.Close()
+
+	server.On("", "").Reply(200, "secure")
+	resp, err := server.Client().R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyString() != "secure" || resp.Timing().TLSHandshake <= 0 {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+}
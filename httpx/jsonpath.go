@@ -0,0 +1,212 @@
+package httpx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep JSONPath 中的一级选择器
+type jsonPathStep struct {
+	recursive bool   // 是否为 .. 递归查找
+	wildcard  bool   // 是否为 * 或 [*]
+	key       string // 对象的键
+	index     *int   // 数组下标，支持负数
+	slice     bool   // 是否为数组切片 [start:end]
+	start     *int
+	end       *int
+}
+
+// parseJSONPath 解析 JSONPath，支持 $、.key、['key']、[n]、[-n]、[*]、.*、..key、..*、[start:end]
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+
+	var steps []jsonPathStep
+	for i := 0; i < len(path); {
+		step := jsonPathStep{}
+		switch path[i] {
+		case '.':
+			i++
+			if i < len(path) && path[i] == '.' {
+				step.recursive = true
+				i++
+			}
+			if step.recursive && i < len(path) && path[i] == '[' {
+				// ..[0]、..['key'] 形式
+				n, err := step.parseBracket(path, i)
+				if err != nil {
+					return nil, err
+				}
+				i = n
+				break
+			}
+			j := i
+			for j < len(path) && path[j] != '.' && path[j] != '[' {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("invalid jsonpath %s: empty key at %d", path, i)
+			}
+			if key := path[i:j]; key == "*" {
+				step.wildcard = true
+			} else {
+				step.key = key
+			}
+			i = j
+		case '[':
+			n, err := step.parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			i = n
+		default:
+			if i > 0 {
+				return nil, fmt.Errorf("invalid jsonpath %s: unexpected %q at %d", path, path[i], i)
+			}
+			// 省略 $ 与开头的 .，如 data.items[0]
+			path = "." + path
+			continue
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// parseBracket 解析 path[i] 开始的括号选择器，返回括号之后的位置
+func (s *jsonPathStep) parseBracket(path string, i int) (int, error) {
+	j := strings.IndexByte(path[i:], ']')
+	if j < 0 {
+		return 0, fmt.Errorf("invalid jsonpath %s: missing ]", path)
+	}
+	inner := strings.TrimSpace(path[i+1 : i+j])
+
+	switch {
+	case inner == "*":
+		s.wildcard = true
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		s.key = inner[1 : len(inner)-1]
+	case strings.Contains(inner, ":"):
+		s.slice = true
+		start, end, _ := strings.Cut(inner, ":")
+		for _, bound := range []struct {
+			value string
+			dst   **int
+		}{{start, &s.start}, {end, &s.end}} {
+			if bound.value = strings.TrimSpace(bound.value); bound.value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(bound.value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid jsonpath %s: invalid slice [%s]", path, inner)
+			}
+			*bound.dst = &n
+		}
+	default:
+		n, err := strconv.Atoi(inner)
+		if err != nil {
+			return 0, fmt.Errorf("invalid jsonpath %s: unsupported selector [%s]", path, inner)
+		}
+		s.index = &n
+	}
+	return i + j + 1, nil
+}
+
+// evalJSONPath 在 json.Unmarshal 得到的数据上执行 JSONPath，返回所有匹配的值
+func evalJSONPath(doc any, path string) ([]any, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []any{doc}
+	for _, step := range steps {
+		if step.recursive {
+			var all []any
+			for _, v := range current {
+				all = appendDescendants(all, v)
+			}
+			current = all
+		}
+		var next []any
+		for _, v := range current {
+			next = step.apply(next, v)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// apply 对单个值执行选择器
+func (s *jsonPathStep) apply(dst []any, v any) []any {
+	switch x := v.(type) {
+	case map[string]any:
+		if s.wildcard {
+			for _, key := range sortedKeys(x) {
+				dst = append(dst, x[key])
+			}
+		} else if s.index == nil && !s.slice {
+			if value, ok := x[s.key]; ok {
+				dst = append(dst, value)
+			}
+		}
+	case []any:
+		switch {
+		case s.wildcard:
+			dst = append(dst, x...)
+		case s.index != nil:
+			i := *s.index
+			if i < 0 {
+				i += len(x)
+			}
+			if i >= 0 && i < len(x) {
+				dst = append(dst, x[i])
+			}
+		case s.slice:
+			start, end := 0, len(x)
+			if s.start != nil {
+				start = *s.start
+			}
+			if s.end != nil {
+				end = *s.end
+			}
+			if start < 0 {
+				start += len(x)
+			}
+			if end < 0 {
+				end += len(x)
+			}
+			start, end = max(0, min(start, len(x))), max(0, min(end, len(x)))
+			if start < end {
+				dst = append(dst, x[start:end]...)
+			}
+		}
+	}
+	return dst
+}
+
+// appendDescendants 按先序遍历追加值本身及其所有后代
+func appendDescendants(dst []any, v any) []any {
+	dst = append(dst, v)
+	switch x := v.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(x) {
+			dst = appendDescendants(dst, x[key])
+		}
+	case []any:
+		for _, item := range x {
+			dst = appendDescendants(dst, item)
+		}
+	}
+	return dst
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"store": {"book": [{"title": "a", "price": 8}, {"title": "b", "price": 12},
+		{"title": "c", "price": 9}], "bicycle": {"price": 20}}, "name with space": 1}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$", "[map[name with space:1 store:map[bicycle:map[price:20] book:[map[price:8 title:a] map[price:12 title:b] map[price:9 title:c]]]]]"},
+		{"$.store.book[0].title", "[a]"},
+		{"store.book[-1].title", "[c]"},
+		{"$['store']['bicycle'].price", "[20]"},
+		{`$["name with space"]`, "[1]"},
+		{"$.store.book[*].title", "[a b c]"},
+		{"$.store.book[1:].title", "[b c]"},
+		{"$.store.book[:-2].title", "[a]"},
+		{"$..price", "[20 8 12 9]"},
+		{"$..book[2].title", "[c]"},
+		{"$..[0].title", "[a]"},
+		{"$.store.*.price", "[20]"},
+		{"$.store.book[5]", "[]"},
+		{"$.missing.key", "[]"},
+	}
+	for _, tt := range tests {
+		values, err := evalJSONPath(doc, tt.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if got := fmt.Sprint(values); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.path, tt.want, got)
+		}
+	}
+
+	for _, invalid := range []string{"$.store[", "$.store[?(@.price)]", "$.store..", "$.store[a:b]"} {
+		if _, err := evalJSONPath(doc, invalid); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
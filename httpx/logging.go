@@ -0,0 +1,254 @@
+package httpx
+
+import (
+	"bytes"
+	"github.com/kelesec/gopkg/logger"
+	"github.com/valyala/fasthttp"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// redactedValue 脱敏后的值
+const redactedValue = "******"
+
+// DefaultRedactFields 默认脱敏的请求体、响应体与查询参数字段
+var DefaultRedactFields = []string{
+	"password", "passwd", "pwd", "secret", "client_secret", "token", "access_token", "refresh_token", "api_key", "apikey",
+}
+
+// LogConfig 请求日志配置，日志通过 logger 包输出，日志级别与输出位置由 `logger.InitLogger` 决定。
+// Level 的零值为 DebugLevel，建议通过 `DefaultLogConfig` 创建后再修改
+type LogConfig struct {
+	SummaryLevel  logger.Level // 请求摘要（方法、地址、状态码、耗时、大小）的日志级别
+	DumpLevel     logger.Level // 完整请求与响应（请求头、响应头、请求体、响应体）的日志级别
+	MaxBodySize   int          // 完整日志中请求体、响应体输出的最大字节数，小于等于 0 时不输出
+	RedactHeaders []string     // 额外需要脱敏的请求头、响应头，Authorization、Proxy-Authorization、Cookie、Set-Cookie 始终脱敏
+	RedactFields  []string     // 需要脱敏的请求体、响应体（按 Content-Type 处理 JSON、表单）以及查询参数字段，忽略大小写
+}
+
+// DefaultLogConfig 默认的请求日志配置，摘要为 Info 级别，完整请求与响应为 Debug 级别
+func DefaultLogConfig() *LogConfig {
+	return &LogConfig{
+		SummaryLevel: logger.InfoLevel,
+		DumpLevel:    logger.DebugLevel,
+		MaxBodySize:  4 * 1024,
+		RedactFields: DefaultRedactFields,
+	}
+}
+
+// requestLogger 记录每次请求（重定向时为每一跳）的日志
+type requestLogger struct {
+	config     LogConfig
+	headers    map[string]bool // 需要脱敏的请求头，小写
+	jsonFields *regexp.Regexp  // 匹配 JSON 中需要脱敏的字段名，字段值在其后
+	formFields *regexp.Regexp  // 匹配表单、查询参数中需要脱敏的字段值
+}
+
+func newRequestLogger(config LogConfig) *requestLogger {
+	l := &requestLogger{
+		config: config,
+		headers: map[string]bool{
+			"authorization": true, "proxy-authorization": true, "cookie": true, "set-cookie": true,
+		},
+	}
+	for _, header := range config.RedactHeaders {
+		l.headers[strings.ToLower(header)] = true
+	}
+	if len(config.RedactFields) > 0 {
+		fields := make([]string, len(config.RedactFields))
+		for i, field := range config.RedactFields {
+			fields[i] = regexp.QuoteMeta(field)
+		}
+		names := strings.Join(fields, "|")
+		l.jsonFields = regexp.MustCompile(`"(?i:` + names + `)"\s*:\s*`)
+		l.formFields = regexp.MustCompile(`((?:^|[?&;])(?i:` + names + `)=)[^&;#\s]*`)
+	}
+	return l
+}
+
+// log 记录请求摘要与完整的请求、响应
+func (l *requestLogger) log(req *fasthttp.Request, resp *fasthttp.Response, ex *exchange, latency time.Duration, err error) {
+	if event := logger.Event(l.config.SummaryLevel); event.Enabled() {
+		event.Str("method", string(req.Header.Method())).Str("url", l.redactForm(requestURL(req)))
+		if err != nil {
+			event.Err(err)
+		} else {
+			event.Int("status", resp.StatusCode()).
+				Int("size", len(resp.Body())).
+				Str("proto", string(resp.Header.Protocol()))
+			if ex.cacheStatus != CacheNone {
+				event.Str("cache", string(ex.cacheStatus))
+			}
+			if ex.timing != nil && ex.timing.RemoteAddr != "" {
+				event.Str("remote", ex.timing.RemoteAddr)
+			}
+		}
+		event.Dur("latency", latency).Msg("http request")
+	}
+
+	if event := logger.Event(l.config.DumpLevel); event.Enabled() {
+		event.Str("request", l.dump(req.Header.Header(), req.Body()))
+		if err == nil {
+			event.Str("response", l.dump(resp.Header.Header(), resp.Body()))
+		}
+		event.Msg("http exchange")
+	}
+}
+
+// requestURL 获取请求地址，使用连接路由时主机为 URL 中的主机
+func requestURL(req *fasthttp.Request) string {
+	uri := req.URI()
+	host := string(uri.Host())
+	if _, _, _, routed := parseRouteAddr(host); routed {
+		host = string(req.Header.Host())
+	}
+	return string(uri.Scheme()) + "://" + host + string(uri.RequestURI())
+}
+
+// dump 将脱敏后的请求头（响应头）与请求体（响应体）拼接为报文
+func (l *requestLogger) dump(header, body []byte) string {
+	var b strings.Builder
+	var contentType string
+	lines := strings.Split(strings.TrimRight(string(header), "\r\n"), "\r\n")
+	for i, line := range lines {
+		if i == 0 {
+			// 请求行中的查询参数同样需要脱敏
+			line = l.redactForm(line)
+		} else if key, value, ok := strings.Cut(line, ": "); ok {
+			if strings.EqualFold(key, "Content-Type") {
+				contentType = strings.ToLower(value)
+			}
+			if l.headers[strings.ToLower(key)] {
+				line = key + ": " + redactHeader(key, value)
+			}
+		}
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n")
+
+	if l.config.MaxBodySize <= 0 || len(body) == 0 {
+		return b.String()
+	}
+	truncated := len(body) > l.config.MaxBodySize
+	if truncated {
+		body = body[:l.config.MaxBodySize]
+		// 截断位置可能位于多字节字符中间
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(body); i++ {
+			body = body[:len(body)-1]
+		}
+	}
+	if bytes.IndexByte(body, 0) >= 0 || !utf8.Valid(body) {
+		b.WriteString("[binary body]")
+		return b.String()
+	}
+	b.WriteString(l.redactBody(contentType, string(body)))
+	if truncated {
+		b.WriteString("...(truncated)")
+	}
+	return b.String()
+}
+
+// redactBody 按 Content-Type 脱敏 JSON 或表单中的字段，其他类型的内容保持不变
+func (l *requestLogger) redactBody(contentType, body string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	switch {
+	case mediaType == MIMEApplicationForm:
+		return l.redactForm(body)
+	case strings.HasSuffix(mediaType, "/json"), strings.HasSuffix(mediaType, "+json"):
+		return l.redactJSON(body)
+	}
+	return body
+}
+
+// redactForm 脱敏表单以及查询参数中的字段
+func (l *requestLogger) redactForm(s string) string {
+	if l.formFields == nil {
+		return s
+	}
+	return l.formFields.ReplaceAllString(s, "${1}"+redactedValue)
+}
+
+// redactJSON 脱敏 JSON 中的字段，字段值为对象、数组时整体替换
+func (l *requestLogger) redactJSON(s string) string {
+	if l.jsonFields == nil {
+		return s
+	}
+	var b strings.Builder
+	pos := 0
+	for _, loc := range l.jsonFields.FindAllStringIndex(s, -1) {
+		if loc[0] < pos {
+			// 位于已脱敏的字段值内
+			continue
+		}
+		b.WriteString(s[pos:loc[1]])
+		b.WriteString(`"` + redactedValue + `"`)
+		pos = skipJSONValue(s, loc[1])
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+// skipJSONValue 获取从 i 开始的 JSON 值的结束位置，内容被截断时返回 len(s)
+func skipJSONValue(s string, i int) int {
+	depth := 0
+	for i < len(s) {
+		switch c := s[i]; c {
+		case '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return len(s)
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ',', ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+		if depth == 0 && (s[i-1] == '"' || s[i-1] == '}' || s[i-1] == ']') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// redactHeader 脱敏请求头的值，保留认证方式以及 Cookie 名称、属性
+func redactHeader(key, value string) string {
+	switch strings.ToLower(key) {
+	case "authorization", "proxy-authorization":
+		if scheme, _, ok := strings.Cut(value, " "); ok {
+			return scheme + " " + redactedValue
+		}
+	case "cookie":
+		cookies := strings.Split(value, ";")
+		for i, cookie := range cookies {
+			if name, _, ok := strings.Cut(cookie, "="); ok {
+				cookies[i] = name + "=" + redactedValue
+			}
+		}
+		return strings.Join(cookies, ";")
+	case "set-cookie":
+		pair, attrs, _ := strings.Cut(value, ";")
+		if name, _, ok := strings.Cut(pair, "="); ok {
+			if attrs != "" {
+				attrs = ";" + attrs
+			}
+			return name + "=" + redactedValue + attrs
+		}
+	}
+	return redactedValue
+}
@@ -0,0 +1,161 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kelesec/gopkg/logger"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs 将 logger 包的输出重定向到内存中，返回按行解析的日志
+func captureLogs(t *testing.T, level zerolog.Level) func() []map[string]any {
+	var buf bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&buf).Level(level)
+	t.Cleanup(func() {
+		log.Logger = original
+	})
+	return func() []map[string]any {
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := make(map[string]any)
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("invalid log line %q: %v", line, err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestClientLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user":"admin","access_token":"tok-123","count":1}`))
+	}))
+	defer server.Close()
+
+	entries := captureLogs(t, zerolog.TraceLevel)
+	config := DefaultLogConfig()
+	config.RedactHeaders = []string{"X-Api-Key"}
+	client := NewClient().SetLogger(config)
+	_, err := client.R().
+		SetHeader("Authorization", "Bearer abc.def").
+		SetHeader("X-Api-Key", "key-123").
+		SetCookie("sid", "cookie-secret").
+		SetQueryParam("token", "query-secret").
+		SetContentType(MIMEApplicationJSON).
+		SetBodyString(`{"name":"admin","password":"p@ss"}`).
+		Post(server.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := entries()
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 log entries, got %d: %v", len(logs), logs)
+	}
+	summary, dump := logs[0], logs[1]
+	if summary["level"] != "info" || summary["message"] != "http request" || summary["method"] != "POST" ||
+		summary["status"] != float64(200) || summary["url"] != server.URL+"/login?token=******" {
+		t.Fatalf("unexpected summary: %v", summary)
+	}
+	if dump["level"] != "debug" || dump["message"] != "http exchange" {
+		t.Fatalf("unexpected dump: %v", dump)
+	}
+
+	request, response := dump["request"].(string), dump["response"].(string)
+	for _, secret := range []string{"abc.def", "key-123", "cookie-secret", "query-secret", "p@ss"} {
+		if strings.Contains(request, secret) {
+			t.Fatalf("secret %q leaked in request dump:\n%s", secret, request)
+		}
+	}
+	for _, expected := range []string{"Authorization: Bearer ******", "X-Api-Key: ******", "sid=******", `"password":"******"`, `"name":"admin"`} {
+		if !strings.Contains(request, expected) {
+			t.Fatalf("%q not found in request dump:\n%s", expected, request)
+		}
+	}
+	if strings.Contains(response, "server-secret") || strings.Contains(response, "tok-123") ||
+		!strings.Contains(response, "session=******; Path=/") || !strings.Contains(response, `"count":1`) {
+		t.Fatalf("unexpected response dump:\n%s", response)
+	}
+
+	// 日志级别高于 Debug 时只输出摘要
+	entries = captureLogs(t, zerolog.InfoLevel)
+	if _, err = client.R().Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if logs = entries(); len(logs) != 1 || logs[0]["message"] != "http request" {
+		t.Fatalf("expected only summary, got %v", logs)
+	}
+
+	// 关闭日志
+	entries = captureLogs(t, zerolog.TraceLevel)
+	if _, err = client.SetLogger(nil).R().Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if logs = entries(); len(logs) != 0 {
+		t.Fatalf("expected no logs, got %v", logs)
+	}
+}
+
+func TestClientLoggerError(t *testing.T) {
+	entries := captureLogs(t, zerolog.TraceLevel)
+	config := DefaultLogConfig()
+	config.SummaryLevel = logger.WarnLevel
+	client := NewClient().SetLogger(config)
+	if _, err := client.R().Get("http://127.0.0.1:1/"); err == nil {
+		t.Fatal("expected error")
+	}
+	logs := entries()
+	if len(logs) != 2 || logs[0]["level"] != "warn" || logs[0]["error"] == nil || logs[1]["response"] != nil {
+		t.Fatalf("unexpected logs: %v", logs)
+	}
+}
+
+func TestLoggerDumpBody(t *testing.T) {
+	l := newRequestLogger(LogConfig{MaxBodySize: 8})
+	header := []byte("POST / HTTP/1.1\r\nHost: a\r\n\r\n")
+	if dump := l.dump(header, []byte("0123456789")); !strings.HasSuffix(dump, "\r\n\r\n01234567...(truncated)") {
+		t.Fatalf("unexpected dump %q", dump)
+	}
+	if dump := l.dump(header, []byte{0x00, 0x01}); !strings.HasSuffix(dump, "[binary body]") {
+		t.Fatalf("unexpected dump %q", dump)
+	}
+	// 不会在多字节字符中间截断
+	if dump := l.dump(header, []byte("中文内容")); !strings.HasSuffix(dump, "中文...(truncated)") {
+		t.Fatalf("unexpected dump %q", dump)
+	}
+	if dump := l.dump(header, []byte("pwd=1")); !strings.HasSuffix(dump, "pwd=1") {
+		t.Fatalf("fields should not be redacted without RedactFields: %q", dump)
+	}
+}
+
+func TestLoggerRedactBody(t *testing.T) {
+	l := newRequestLogger(*DefaultLogConfig())
+	tests := []struct {
+		contentType, body, expected string
+	}{
+		{"application/json", `{"token": {"a":1,"b":"}"}, "x":1}`, `{"token": "******", "x":1}`},
+		{"application/json; charset=utf-8", `{"secret":[1,[2]],"pwd":null}`, `{"secret":"******","pwd":"******"}`},
+		{"application/problem+json", `{"Password":"a\"b"}`, `{"Password":"******"}`},
+		{"application/json", `{"token":{"a":`, `{"token":"******"`},
+		{MIMEApplicationForm, "user=a&password=b", "user=a&password=******"},
+		{"text/plain", "password=b", "password=b"},
+		{"text/html", `"token": "a"`, `"token": "a"`},
+	}
+	for _, test := range tests {
+		if actual := l.redactBody(test.contentType, test.body); actual != test.expected {
+			t.Errorf("redactBody(%s, %s) = %s, expected %s", test.contentType, test.body, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,599 @@
+package match
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/kelesec/gopkg/httpx"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Env DSL 表达式的变量
+type Env map[string]any
+
+// NewEnv 根据响应生成 DSL 变量：
+//   - status_code、content_length：状态码、响应体长度
+//   - body、header、all：响应体、响应头、响应头 + 响应体
+//   - location、duration：重定向地址、请求耗时（秒）
+//   - 所有响应头，名称转为小写并将 - 替换为 _，如 content_type
+func NewEnv(resp *httpx.Response) Env {
+	env := Env{
+		"status_code":    int64(resp.Status()),
+		"content_length": int64(resp.ContentLength()),
+		"body":           resp.BodyString(),
+		"header":         resp.HeaderString(),
+		"all":            resp.HeaderString() + resp.BodyString(),
+		"location":       resp.Location(),
+		"duration":       float64(0),
+	}
+	if timing := resp.Timing(); timing != nil {
+		env["duration"] = timing.Total.Seconds()
+	}
+	for key, value := range resp.Header() {
+		name := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		if _, ok := env[name]; !ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// Expr 解析后的 DSL 表达式，支持：
+//   - 字面量：整数、小数、字符串（单引号或双引号）、true、false
+//   - 运算符：|| && ! == != < <= > >= 以及括号
+//   - 函数：contains、contains_all、contains_any、starts_with、ends_with、regex、len、
+//     to_lower、to_upper、md5、sha1、sha256、mmh3、base64、base64_decode
+//
+// @example: status_code == 200 && contains(body, "admin") && !regex("(?i)error", body)
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse 解析 DSL 表达式
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, fmt.Errorf("parse dsl %q failed: %v", src, err)
+	}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected token %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse dsl %q failed: %v", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval 计算表达式的值
+func (e *Expr) Eval(env Env) (any, error) {
+	return e.root.eval(env)
+}
+
+// Bool 计算表达式的值，结果必须为布尔值
+func (e *Expr) Bool(env Env) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("dsl %q is not a boolean expression", e.src)
+	}
+	return b, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval 解析并计算 DSL 表达式，结果必须为布尔值
+func Eval(src string, resp *httpx.Response) (bool, error) {
+	expr, err := Parse(src)
+	if err != nil {
+		return false, err
+	}
+	return expr.Bool(NewEnv(resp))
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// tokenize 词法分析
+func (p *parser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+					switch s[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 'r':
+						sb.WriteByte('\r')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(s[j])
+					}
+					continue
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unterminated string at %d", i)
+			}
+			p.tokens = append(p.tokens, token{tokenString, sb.String()})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokenNumber, s[i:j]})
+			i = j
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokenIdent, s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", ",", "-"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			p.tokens = append(p.tokens, token{tokenOp, op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOp && p.tokens[p.pos].text == op
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.peek("||") {
+		p.pos++
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left = &logicNode{op: "||", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseCompare()
+	for err == nil && p.peek("&&") {
+		p.pos++
+		var right node
+		if right, err = p.parseCompare(); err == nil {
+			left = &logicNode{op: "&&", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.peek(op) {
+			p.pos++
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return &compareNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek("!") || p.peek("-") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokenNumber:
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return &literalNode{value: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return &literalNode{value: f}, nil
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		if !p.peek("(") {
+			return &identNode{name: tok.text}, nil
+		}
+
+		fn, ok := dslFuncs[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s", tok.text)
+		}
+		p.pos++
+		call := &callNode{name: tok.text, fn: fn}
+		for !p.peek(")") {
+			if len(call.args) > 0 {
+				if !p.peek(",") {
+					return nil, fmt.Errorf("expected , in call of %s", tok.text)
+				}
+				p.pos++
+			}
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+		}
+		p.pos++
+		return call, nil
+	default:
+		if tok.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek(")") {
+				return nil, fmt.Errorf("missing )")
+			}
+			p.pos++
+			return inner, nil
+		}
+		return nil, fmt.Errorf("unexpected token %q", tok.text)
+	}
+}
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(Env) (any, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(env Env) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %s", n.name)
+	}
+	return v, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(env Env) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! requires boolean, got %T", v)
+		}
+		return !b, nil
+	}
+	switch x := v.(type) {
+	case int64:
+		return -x, nil
+	case float64:
+		return -x, nil
+	}
+	return nil, fmt.Errorf("operator - requires number, got %T", v)
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicNode) eval(env Env) (any, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	// 短路求值
+	if (n.op == "||") == left {
+		return left, nil
+	}
+	return evalBool(n.right, env)
+}
+
+func evalBool(n node, env Env) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected boolean, got %T", v)
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmp int
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	switch {
+	case lok && rok:
+		cmp = compare(lf, rf)
+	default:
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if !lok || !rok {
+			if n.op == "==" || n.op == "!=" {
+				return (left == right) == (n.op == "=="), nil
+			}
+			return nil, fmt.Errorf("cannot compare %T with %T", left, right)
+		}
+		cmp = strings.Compare(ls, rs)
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type callNode struct {
+	name string
+	fn   dslFunc
+	args []node
+}
+
+func (n *callNode) eval(env Env) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	if len(args) < n.fn.minArgs || (n.fn.maxArgs >= 0 && len(args) > n.fn.maxArgs) {
+		return nil, fmt.Errorf("invalid number of arguments for %s", n.name)
+	}
+
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		switch x := arg.(type) {
+		case string:
+			strs[i] = x
+		case int64:
+			strs[i] = strconv.FormatInt(x, 10)
+		case float64:
+			strs[i] = strconv.FormatFloat(x, 'f', -1, 64)
+		case bool:
+			strs[i] = strconv.FormatBool(x)
+		}
+	}
+	return n.fn.call(strs)
+}
+
+// dslFunc DSL 函数，参数统一转换为字符串
+type dslFunc struct {
+	minArgs int
+	maxArgs int // 为 -1 时不限制
+	call    func(args []string) (any, error)
+}
+
+// maxRegexCache regex 函数缓存的正则表达式数量上限
+const maxRegexCache = 256
+
+// regexCache 缓存 regex 函数编译的正则表达式，正则表达式可能来自外部输入，数量达到上限时清空
+var regexCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// compileRegex 编译正则表达式，优先使用缓存
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCache.Lock()
+	re, ok := regexCache.m[pattern]
+	regexCache.Unlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Lock()
+	defer regexCache.Unlock()
+	if len(regexCache.m) >= maxRegexCache {
+		clear(regexCache.m)
+	}
+	regexCache.m[pattern] = re
+	return re, nil
+}
+
+func stringFunc(f func(string) string) dslFunc {
+	return dslFunc{1, 1, func(args []string) (any, error) {
+		return f(args[0]), nil
+	}}
+}
+
+func hashFunc(name string) dslFunc {
+	return stringFunc(func(s string) string {
+		return hashFuncs[name]([]byte(s))
+	})
+}
+
+// dslFuncs 支持的 DSL 函数
+var dslFuncs = map[string]dslFunc{
+	"contains": {2, 2, func(args []string) (any, error) {
+		return strings.Contains(args[0], args[1]), nil
+	}},
+	"contains_all": {2, -1, func(args []string) (any, error) {
+		for _, sub := range args[1:] {
+			if !strings.Contains(args[0], sub) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}},
+	"contains_any": {2, -1, func(args []string) (any, error) {
+		for _, sub := range args[1:] {
+			if strings.Contains(args[0], sub) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}},
+	"starts_with": {2, 2, func(args []string) (any, error) {
+		return strings.HasPrefix(args[0], args[1]), nil
+	}},
+	"ends_with": {2, 2, func(args []string) (any, error) {
+		return strings.HasSuffix(args[0], args[1]), nil
+	}},
+	"regex": {2, 2, func(args []string) (any, error) {
+		re, err := compileRegex(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %v", args[0], err)
+		}
+		return re.MatchString(args[1]), nil
+	}},
+	"len": {1, 1, func(args []string) (any, error) {
+		return int64(len(args[0])), nil
+	}},
+	"to_lower": stringFunc(strings.ToLower),
+	"to_upper": stringFunc(strings.ToUpper),
+	"md5":      hashFunc("md5"),
+	"sha1":     hashFunc("sha1"),
+	"sha256":   hashFunc("sha256"),
+	"mmh3":     hashFunc("mmh3"),
+	"base64": stringFunc(func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}),
+	"base64_decode": {1, 1, func(args []string) (any, error) {
+		b, err := base64.StdEncoding.DecodeString(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64: %v", err)
+		}
+		return string(b), nil
+	}},
+}
@@ -0,0 +1,74 @@
+package match
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	env := Env{
+		"status_code":  int64(302),
+		"body":         `{"version": "1.2.3"}`,
+		"content_type": "application/json",
+		"duration":     1.5,
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`status_code == 302`, true},
+		{`status_code >= 300 && status_code < 400`, true},
+		{`status_code != 302 || contains(body, "version")`, true},
+		{`!(status_code == 302)`, false},
+		{`regex("\\d+\\.\\d+\\.\\d+", body) && starts_with(content_type, 'application/')`, true},
+		{`contains_all(body, "version", "1.2") && !contains_any(body, "error", "fail")`, true},
+		{`len(body) == 20 && to_upper("abc") == "ABC"`, true},
+		{`md5("admin") == "21232f297a57a5a743894a0e4a801fc3"`, true},
+		{`base64_decode(base64("hi")) == "hi"`, true},
+		{`duration > 1 && duration < 2.0 && -1 < 0`, true},
+		{`ends_with(body, "}") == false`, false},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		got, err := expr.Bool(env)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, invalid := range []string{`status_code ==`, `unknown(body)`, `contains(body`, `"unterminated`, `a $ b`} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("%s: expected parse error", invalid)
+		}
+	}
+	for _, invalid := range []string{`missing == 1`, `status_code`, `contains(body)`, `status_code < "a"`} {
+		expr, err := Parse(invalid)
+		if err != nil {
+			t.Fatalf("%s: %v", invalid, err)
+		}
+		if _, err = expr.Bool(env); err == nil {
+			t.Errorf("%s: expected eval error", invalid)
+		}
+	}
+}
+
+func TestRegexCache(t *testing.T) {
+	for i := 0; i < maxRegexCache*2; i++ {
+		if _, err := compileRegex(fmt.Sprintf("^p%d$", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	regexCache.Lock()
+	n := len(regexCache.m)
+	regexCache.Unlock()
+	if n > maxRegexCache {
+		t.Fatalf("expected at most %d cached regexes, got %d", maxRegexCache, n)
+	}
+}
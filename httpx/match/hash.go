@@ -0,0 +1,92 @@
+package match
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"strconv"
+)
+
+// hashFuncs 支持的哈希算法，返回值均为字符串形式
+var hashFuncs = map[string]func([]byte) string{
+	"md5": func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	},
+	"sha1": func(b []byte) string {
+		sum := sha1.Sum(b)
+		return hex.EncodeToString(sum[:])
+	},
+	"sha256": func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	},
+	"mmh3": func(b []byte) string {
+		return strconv.Itoa(int(int32(murmur3(b))))
+	},
+	"favicon": FaviconHash,
+}
+
+// FaviconHash 计算与 Shodan `http.favicon.hash` 一致的图标哈希：
+// 内容按每 76 个字符换行进行 base64 编码后计算 mmh3，结果为有符号整数
+func FaviconHash(b []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(b)
+	buf := make([]byte, 0, len(encoded)+len(encoded)/76+1)
+	for len(encoded) > 76 {
+		buf = append(buf, encoded[:76]...)
+		buf = append(buf, '\n')
+		encoded = encoded[76:]
+	}
+	buf = append(buf, encoded...)
+	buf = append(buf, '\n')
+	return strconv.Itoa(int(int32(murmur3(buf))))
+}
+
+// murmur3 计算 MurmurHash3 x86 32 位哈希，seed 为 0
+func murmur3(data []byte) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[n:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,47 @@
+package match
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadJSON 从 JSON 中加载匹配器组
+// @example: {"condition": "and", "matchers": [{"type": "status", "status": [200]}]}
+func LoadJSON(data []byte) (*Group, error) {
+	g := &Group{}
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("unmarshal json failed: %v", err)
+	}
+	if err := g.Compile(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LoadYAML 从 YAML 中加载匹配器组，字段与 JSON 一致
+func LoadYAML(data []byte) (*Group, error) {
+	g := &Group{}
+	if err := yaml.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("unmarshal yaml failed: %v", err)
+	}
+	if err := g.Compile(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LoadFile 从文件中加载匹配器组，.json 文件按 JSON 解析，其余按 YAML 解析
+func LoadFile(path string) (*Group, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %v", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return LoadJSON(data)
+	}
+	return LoadYAML(data)
+}
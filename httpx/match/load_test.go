@@ -0,0 +1,58 @@
+package match
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	resp := newResponse(t, 200, "Welcome to phpMyAdmin", "Set-Cookie", "pma_lang=en")
+
+	yamlRule := `
+condition: and
+matchers:
+  - type: status
+    status: [200]
+  - type: word
+    part: body
+    words: ["phpmyadmin"]
+    case-insensitive: true
+  - type: header
+    headers:
+      set-cookie: pma_
+  - type: regex
+    regex: ["(?i)error"]
+    negative: true
+`
+	g, err := LoadYAML([]byte(yamlRule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Match(resp) {
+		t.Error("yaml rule should match")
+	}
+
+	jsonRule := `{"condition": "or", "matchers": [{"type": "dsl", "dsl": ["status_code == 500"]}, {"type": "status", "status": [200]}]}`
+	path := filepath.Join(t.TempDir(), "rule.json")
+	if err = os.WriteFile(path, []byte(jsonRule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if g, err = LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Match(resp) {
+		t.Error("json rule should match")
+	}
+
+	for _, invalid := range []string{
+		`{"matchers": [{"type": "unknown"}]}`,
+		`{"matchers": [{"type": "dsl", "dsl": ["status_code =="]}]}`,
+		`{"matchers": [{"type": "binary", "binary": ["zz"]}]}`,
+		`{"condition": "xor", "matchers": []}`,
+	} {
+		if g, err = LoadJSON([]byte(invalid)); err == nil || g != nil {
+			t.Errorf("%s: expected error and nil group", invalid)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Package match 提供声明式的响应匹配器，可组合状态码、关键字、正则、长度、响应头、二进制、哈希以及 DSL 表达式，
+// 匹配规则可以从 YAML、JSON 中加载，使检测逻辑以数据的形式维护
+package match
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/kelesec/gopkg/httpx"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Type 匹配器类型
+type Type string
+
+const (
+	TypeStatus Type = "status" // 匹配状态码
+	TypeWord   Type = "word"   // 匹配关键字
+	TypeRegex  Type = "regex"  // 匹配正则表达式
+	TypeSize   Type = "size"   // 匹配内容长度
+	TypeHeader Type = "header" // 匹配响应头
+	TypeBinary Type = "binary" // 匹配十六进制表示的二进制内容
+	TypeHash   Type = "hash"   // 匹配内容哈希
+	TypeDSL    Type = "dsl"    // 匹配 DSL 表达式
+)
+
+// Part 匹配的响应部分
+type Part string
+
+const (
+	PartBody   Part = "body"   // 响应体，默认值
+	PartHeader Part = "header" // 响应头
+	PartAll    Part = "all"    // 响应头 + 响应体
+)
+
+// Condition 多个条件之间的逻辑关系
+type Condition string
+
+const (
+	ConditionOr  Condition = "or"  // 任意条件满足即可，默认值
+	ConditionAnd Condition = "and" // 所有条件都需要满足
+)
+
+// Matcher 响应匹配器，同一匹配器的多个匹配值之间按 Condition 组合
+type Matcher struct {
+	Type            Type              `json:"type" yaml:"type"`                                             // 匹配器类型
+	Name            string            `json:"name,omitempty" yaml:"name,omitempty"`                         // 匹配器名称
+	Part            Part              `json:"part,omitempty" yaml:"part,omitempty"`                         // 匹配的响应部分，默认为响应体
+	Condition       Condition         `json:"condition,omitempty" yaml:"condition,omitempty"`               // 多个匹配值之间的逻辑关系，默认为 or
+	Negative        bool              `json:"negative,omitempty" yaml:"negative,omitempty"`                 // 对匹配结果取反
+	CaseInsensitive bool              `json:"case-insensitive,omitempty" yaml:"case-insensitive,omitempty"` // word 匹配时忽略大小写
+	Status          []int             `json:"status,omitempty" yaml:"status,omitempty"`                     // 状态码
+	Size            []int             `json:"size,omitempty" yaml:"size,omitempty"`                         // 内容长度
+	Words           []string          `json:"words,omitempty" yaml:"words,omitempty"`                       // 关键字
+	Regex           []string          `json:"regex,omitempty" yaml:"regex,omitempty"`                       // 正则表达式
+	Headers         map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`                   // 响应头名称及值的正则表达式，值为空时只要求响应头存在
+	Binary          []string          `json:"binary,omitempty" yaml:"binary,omitempty"`                     // 十六进制表示的二进制内容
+	Hash            string            `json:"hash,omitempty" yaml:"hash,omitempty"`                         // 哈希算法，支持 md5、sha1、sha256、mmh3、favicon
+	Hashes          []string          `json:"hashes,omitempty" yaml:"hashes,omitempty"`                     // 哈希值
+	DSL             []string          `json:"dsl,omitempty" yaml:"dsl,omitempty"`                           // DSL 表达式，参考 `Eval`
+
+	once     sync.Once
+	err      error
+	regexps  []*regexp.Regexp
+	headers  map[string]*regexp.Regexp
+	binaries [][]byte
+	exprs    []*Expr
+}
+
+// Compile 校验并预编译匹配器，Match 时会自动编译，提前调用可以尽早发现规则错误
+func (m *Matcher) Compile() error {
+	m.once.Do(func() {
+		m.err = m.compile()
+	})
+	return m.err
+}
+
+func (m *Matcher) compile() error {
+	if m.Condition != "" && m.Condition != ConditionOr && m.Condition != ConditionAnd {
+		return fmt.Errorf("invalid condition: %s", m.Condition)
+	}
+	switch m.Part {
+	case "", PartBody, PartHeader, PartAll:
+	default:
+		return fmt.Errorf("invalid part: %s", m.Part)
+	}
+
+	switch m.Type {
+	case TypeStatus, TypeSize, TypeWord:
+	case TypeRegex:
+		for _, pattern := range m.Regex {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid regex %s: %v", pattern, err)
+			}
+			m.regexps = append(m.regexps, re)
+		}
+	case TypeHeader:
+		m.headers = make(map[string]*regexp.Regexp)
+		for name, pattern := range m.Headers {
+			if pattern == "" {
+				m.headers[name] = nil
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid header regex %s: %v", pattern, err)
+			}
+			m.headers[name] = re
+		}
+	case TypeBinary:
+		for _, value := range m.Binary {
+			b, err := hex.DecodeString(value)
+			if err != nil {
+				return fmt.Errorf("invalid binary %s: %v", value, err)
+			}
+			m.binaries = append(m.binaries, b)
+		}
+	case TypeHash:
+		if _, ok := hashFuncs[strings.ToLower(m.Hash)]; !ok {
+			return fmt.Errorf("unsupported hash: %s", m.Hash)
+		}
+	case TypeDSL:
+		for _, expression := range m.DSL {
+			expr, err := Parse(expression)
+			if err != nil {
+				return err
+			}
+			m.exprs = append(m.exprs, expr)
+		}
+	default:
+		return fmt.Errorf("unsupported matcher type: %s", m.Type)
+	}
+	return nil
+}
+
+// Match 判断响应是否匹配，匹配器无效时返回 false
+func (m *Matcher) Match(resp *httpx.Response) bool {
+	if resp == nil || m.Compile() != nil {
+		return false
+	}
+	return m.match(resp) != m.Negative
+}
+
+func (m *Matcher) match(resp *httpx.Response) bool {
+	switch m.Type {
+	case TypeStatus:
+		return m.matchValues(len(m.Status), func(i int) bool {
+			return resp.Status() == m.Status[i]
+		})
+	case TypeSize:
+		size := len(m.content(resp))
+		return m.matchValues(len(m.Size), func(i int) bool {
+			return size == m.Size[i]
+		})
+	case TypeWord:
+		content := m.content(resp)
+		if m.CaseInsensitive {
+			content = bytes.ToLower(content)
+		}
+		return m.matchValues(len(m.Words), func(i int) bool {
+			word := m.Words[i]
+			if m.CaseInsensitive {
+				word = strings.ToLower(word)
+			}
+			return bytes.Contains(content, []byte(word))
+		})
+	case TypeRegex:
+		content := m.content(resp)
+		return m.matchValues(len(m.regexps), func(i int) bool {
+			return m.regexps[i].Match(content)
+		})
+	case TypeHeader:
+		names := make([]string, 0, len(m.headers))
+		for name := range m.headers {
+			names = append(names, name)
+		}
+		return m.matchValues(len(names), func(i int) bool {
+			return matchHeader(resp, names[i], m.headers[names[i]])
+		})
+	case TypeBinary:
+		content := m.content(resp)
+		return m.matchValues(len(m.binaries), func(i int) bool {
+			return bytes.Contains(content, m.binaries[i])
+		})
+	case TypeHash:
+		sum := hashFuncs[strings.ToLower(m.Hash)](m.content(resp))
+		return m.matchValues(len(m.Hashes), func(i int) bool {
+			return strings.EqualFold(sum, m.Hashes[i])
+		})
+	case TypeDSL:
+		env := NewEnv(resp)
+		return m.matchValues(len(m.exprs), func(i int) bool {
+			ok, err := m.exprs[i].Bool(env)
+			return err == nil && ok
+		})
+	}
+	return false
+}
+
+// matchValues 按 Condition 组合多个匹配值的结果，没有匹配值时视为不匹配
+func (m *Matcher) matchValues(n int, match func(i int) bool) bool {
+	if n == 0 {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if match(i) {
+			if m.Condition != ConditionAnd {
+				return true
+			}
+		} else if m.Condition == ConditionAnd {
+			return false
+		}
+	}
+	return m.Condition == ConditionAnd
+}
+
+// content 获取需要匹配的响应内容
+func (m *Matcher) content(resp *httpx.Response) []byte {
+	switch m.Part {
+	case PartHeader:
+		return resp.HeaderBytes()
+	case PartAll:
+		return slices.Concat(resp.HeaderBytes(), resp.Body())
+	default:
+		return resp.Body()
+	}
+}
+
+// matchHeader 忽略大小写查找响应头，同名响应头（如 Set-Cookie）任意一个匹配即可，re 为 nil 时只要求响应头存在
+func matchHeader(resp *httpx.Response, name string, re *regexp.Regexp) bool {
+	for key, value := range resp.OriginalResponse.Header.All() {
+		if strings.EqualFold(string(key), name) && (re == nil || re.Match(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Group 匹配器组，多个匹配器之间按 Condition 组合
+type Group struct {
+	Condition Condition  `json:"condition,omitempty" yaml:"condition,omitempty"` // 多个匹配器之间的逻辑关系，默认为 or
+	Matchers  []*Matcher `json:"matchers" yaml:"matchers"`                       // 匹配器
+}
+
+// And 创建所有匹配器都需要匹配的匹配器组
+func And(matchers ...*Matcher) *Group {
+	return &Group{Condition: ConditionAnd, Matchers: matchers}
+}
+
+// Or 创建任意匹配器匹配即可的匹配器组
+func Or(matchers ...*Matcher) *Group {
+	return &Group{Condition: ConditionOr, Matchers: matchers}
+}
+
+// Compile 校验并预编译所有匹配器
+func (g *Group) Compile() error {
+	if g.Condition != "" && g.Condition != ConditionOr && g.Condition != ConditionAnd {
+		return fmt.Errorf("invalid condition: %s", g.Condition)
+	}
+	for i, m := range g.Matchers {
+		if err := m.Compile(); err != nil {
+			return fmt.Errorf("matcher %d (%s): %v", i, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Match 判断响应是否匹配
+func (g *Group) Match(resp *httpx.Response) bool {
+	ok, _ := g.match(resp, false)
+	return ok
+}
+
+// MatchNames 判断响应是否匹配，并返回匹配成功的匹配器名称
+func (g *Group) MatchNames(resp *httpx.Response) (bool, []string) {
+	return g.match(resp, true)
+}
+
+func (g *Group) match(resp *httpx.Response, all bool) (bool, []string) {
+	if len(g.Matchers) == 0 {
+		return false, nil
+	}
+
+	var names []string
+	matched := g.Condition == ConditionAnd
+	for _, m := range g.Matchers {
+		if !m.Match(resp) {
+			if g.Condition == ConditionAnd {
+				return false, nil
+			}
+			continue
+		}
+		if m.Name != "" {
+			names = append(names, m.Name)
+		}
+		if g.Condition != ConditionAnd {
+			matched = true
+			if !all {
+				break
+			}
+		}
+	}
+	return matched, names
+}
@@ -0,0 +1,101 @@
+package match
+
+import (
+	"github.com/kelesec/gopkg/httpx"
+	"github.com/kelesec/gopkg/httpx/httpxtest"
+	"strings"
+	"testing"
+)
+
+// newResponse 通过测试服务器获取响应
+func newResponse(t *testing.T, status int, body string, headers ...string) *httpx.Response {
+	t.Helper()
+	server := httpxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	route := server.On("", "").Reply(status, body)
+	for i := 0; i+1 < len(headers); i += 2 {
+		route.Header(headers[i], headers[i+1])
+	}
+	resp, err := server.Client().R().Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestMatcher(t *testing.T) {
+	resp := newResponse(t, 200, "<title>Admin Console</title>\x00\x01", "Server", "nginx/1.25.3", "X-Powered-By", "PHP/8.1")
+
+	tests := []struct {
+		name    string
+		matcher *Matcher
+		want    bool
+	}{
+		{"status", &Matcher{Type: TypeStatus, Status: []int{301, 200}}, true},
+		{"status negative", &Matcher{Type: TypeStatus, Status: []int{200}, Negative: true}, false},
+		{"word or", &Matcher{Type: TypeWord, Words: []string{"missing", "Admin"}}, true},
+		{"word and", &Matcher{Type: TypeWord, Words: []string{"missing", "Admin"}, Condition: ConditionAnd}, false},
+		{"word case insensitive", &Matcher{Type: TypeWord, Words: []string{"admin console"}, CaseInsensitive: true}, true},
+		{"word header part", &Matcher{Type: TypeWord, Words: []string{"nginx"}, Part: PartHeader}, true},
+		{"word body part", &Matcher{Type: TypeWord, Words: []string{"nginx"}}, false},
+		{"word all part", &Matcher{Type: TypeWord, Words: []string{"nginx", "Admin"}, Part: PartAll, Condition: ConditionAnd}, true},
+		{"regex", &Matcher{Type: TypeRegex, Regex: []string{`<title>[A-Z]\w+`}}, true},
+		{"size", &Matcher{Type: TypeSize, Size: []int{30}}, true},
+		{"header", &Matcher{Type: TypeHeader, Headers: map[string]string{"server": `^nginx/1\.2`, "X-Powered-By": ""}, Condition: ConditionAnd}, true},
+		{"header missing", &Matcher{Type: TypeHeader, Headers: map[string]string{"X-Missing": ""}}, false},
+		{"binary", &Matcher{Type: TypeBinary, Binary: []string{"0001"}}, true},
+		{"hash", &Matcher{Type: TypeHash, Hash: "md5", Hashes: []string{"21232f297a57a5a743894a0e4a801fc3"}}, false},
+		{"dsl", &Matcher{Type: TypeDSL, DSL: []string{`status_code == 200 && contains(server, "nginx") && len(body) > 10`}}, true},
+		{"invalid", &Matcher{Type: TypeRegex, Regex: []string{"("}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.Match(resp); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.want, got)
+		}
+	}
+
+	m := &Matcher{Type: TypeHash, Hash: "sha256", Hashes: []string{hashFuncs["sha256"](resp.Body())}}
+	if !m.Match(resp) {
+		t.Error("hash: expected match")
+	}
+}
+
+func TestMatchRepeatedHeader(t *testing.T) {
+	resp := newResponse(t, 200, "", "Set-Cookie", "a=1", "Set-Cookie", "PHPSESSID=abc")
+	for _, value := range []string{"^a=1", "^PHPSESSID="} {
+		m := &Matcher{Type: TypeHeader, Headers: map[string]string{"set-cookie": value}}
+		if !m.Match(resp) {
+			t.Errorf("set-cookie %s: expected match", value)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	resp := newResponse(t, 404, "not found")
+	status := &Matcher{Name: "status", Type: TypeStatus, Status: []int{404}}
+	word := &Matcher{Name: "word", Type: TypeWord, Words: []string{"found"}}
+	miss := &Matcher{Name: "miss", Type: TypeWord, Words: []string{"admin"}}
+
+	if !And(status, word).Match(resp) || And(status, miss).Match(resp) {
+		t.Error("and group mismatch")
+	}
+	if !Or(miss, word).Match(resp) || Or(miss).Match(resp) {
+		t.Error("or group mismatch")
+	}
+	if ok, names := Or(status, miss, word).MatchNames(resp); !ok || len(names) != 2 || names[0] != "status" || names[1] != "word" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+func TestHash(t *testing.T) {
+	if got := hashFuncs["mmh3"]([]byte("hello")); got != "613153351" {
+		t.Errorf("unexpected mmh3: %s", got)
+	}
+	if got := FaviconHash([]byte("hello")); got != "1155597304" {
+		t.Errorf("unexpected favicon hash: %s", got)
+	}
+	if got := FaviconHash([]byte(strings.Repeat("x", 200))); got != "-891237183" {
+		t.Errorf("unexpected favicon hash: %s", got)
+	}
+}
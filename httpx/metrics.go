@@ -0,0 +1,562 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 默认的直方图桶
+var (
+	DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10} // 单位为秒
+	DefaultSizeBuckets    = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+)
+
+const (
+	DefaultMetricsMaxHosts = 1000    // 默认区分统计的主机数上限
+	MetricsOtherHost       = "other" // 超过主机数上限后合并统计使用的主机名
+)
+
+// 请求失败的错误类型
+const (
+	ErrorTypeTimeout     = "timeout"            // 连接、读写超时
+	ErrorTypeDNS         = "dns"                // 域名解析失败
+	ErrorTypeRefused     = "connection_refused" // 连接被拒绝
+	ErrorTypeReset       = "connection_reset"   // 连接被重置或提前关闭
+	ErrorTypeTLS         = "tls"                // TLS 握手或证书错误
+	ErrorTypeCircuitOpen = "circuit_open"       // 熔断器打开
+	ErrorTypeBodyTooBig  = "body_too_large"     // 响应体超过限制
+	ErrorTypeOther       = "other"              // 其他错误
+)
+
+// Metrics 请求指标收集器，按主机、请求方法、状态码分类以及错误类型统计请求，
+// 记录耗时与响应体大小的直方图、进行中的请求数以及重试、重定向次数，可以被多个 Client 共享
+type Metrics struct {
+	latencyBuckets []float64
+	sizeBuckets    []float64
+
+	mu        sync.Mutex
+	maxHosts  int
+	hosts     map[string]struct{}      // 已区分统计的主机
+	requests  map[requestLabels]uint64 // 请求失败时状态码分类为 error
+	errors    map[errorLabels]uint64
+	latency   map[string]*histogram
+	size      map[string]*histogram
+	inFlight  map[string]int64
+	retries   map[string]uint64
+	redirects map[string]uint64
+}
+
+type requestLabels struct {
+	host, method, status string
+}
+
+type errorLabels struct {
+	host, method, errorType string
+}
+
+// histogram 直方图，counts 为各个桶（不累计）的数量，最后一个为 +Inf
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics 创建指标收集器，使用默认的直方图桶
+func NewMetrics() *Metrics {
+	return &Metrics{
+		latencyBuckets: DefaultLatencyBuckets,
+		sizeBuckets:    DefaultSizeBuckets,
+		maxHosts:       DefaultMetricsMaxHosts,
+		hosts:          make(map[string]struct{}),
+		requests:       make(map[requestLabels]uint64),
+		errors:         make(map[errorLabels]uint64),
+		latency:        make(map[string]*histogram),
+		size:           make(map[string]*histogram),
+		inFlight:       make(map[string]int64),
+		retries:        make(map[string]uint64),
+		redirects:      make(map[string]uint64),
+	}
+}
+
+// SetLatencyBuckets 设置耗时直方图的桶（单位为秒，需递增），已有的该直方图数据会被清空
+func (m *Metrics) SetLatencyBuckets(buckets ...float64) *Metrics {
+	checkBuckets(buckets)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencyBuckets = buckets
+	clear(m.latency)
+	return m
+}
+
+// SetSizeBuckets 设置响应体大小直方图的桶（单位为字节，需递增），已有的该直方图数据会被清空
+func (m *Metrics) SetSizeBuckets(buckets ...float64) *Metrics {
+	checkBuckets(buckets)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sizeBuckets = buckets
+	clear(m.size)
+	return m
+}
+
+// SetMaxHosts 设置区分统计的主机数上限，达到上限后新主机的统计合并到 MetricsOtherHost，
+// 为 0 时所有主机均合并统计，默认为 DefaultMetricsMaxHosts
+func (m *Metrics) SetMaxHosts(n int) *Metrics {
+	if n < 0 {
+		panic(fmt.Errorf("invalid max hosts: %d", n))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxHosts = n
+	return m
+}
+
+// label 获取主机对应的统计标签，需持有锁
+func (m *Metrics) label(host string) string {
+	if _, ok := m.hosts[host]; ok {
+		return host
+	}
+	if len(m.hosts) >= m.maxHosts {
+		return MetricsOtherHost
+	}
+	m.hosts[host] = struct{}{}
+	return host
+}
+
+func checkBuckets(buckets []float64) {
+	if len(buckets) == 0 {
+		panic(fmt.Errorf("histogram buckets is empty"))
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic(fmt.Errorf("histogram buckets must be increasing: %v", buckets))
+		}
+	}
+}
+
+// Reset 清空所有统计数据
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.requests)
+	clear(m.errors)
+	clear(m.latency)
+	clear(m.size)
+	clear(m.retries)
+	clear(m.redirects)
+	clear(m.hosts)
+	// 进行中的请求仍会结束，因此保留
+	for host, n := range m.inFlight {
+		if n == 0 {
+			delete(m.inFlight, host)
+		} else if host != MetricsOtherHost {
+			m.hosts[host] = struct{}{}
+		}
+	}
+}
+
+// AddRetry 记录一次重试，由自定义的重试逻辑调用，SSE 重连时会自动记录
+func (m *Metrics) AddRetry(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[m.label(host)]++
+}
+
+// addRedirect 记录一次重定向
+func (m *Metrics) addRedirect(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redirects[m.label(host)]++
+}
+
+// begin 记录请求开始
+func (m *Metrics) begin(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[m.label(host)]++
+}
+
+// done 记录请求结束，size 为响应体大小
+func (m *Metrics) done(host, method string, status int, size int, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	host = m.label(host)
+	m.inFlight[host]--
+
+	if err != nil {
+		m.requests[requestLabels{host, method, "error"}]++
+		m.errors[errorLabels{host, method, classifyError(err)}]++
+		return
+	}
+	m.requests[requestLabels{host, method, statusClass(status)}]++
+	observe(m.latency, host, m.latencyBuckets, latency.Seconds())
+	observe(m.size, host, m.sizeBuckets, float64(size))
+}
+
+func observe(histograms map[string]*histogram, host string, buckets []float64, value float64) {
+	h, ok := histograms[host]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets)+1)}
+		histograms[host] = h
+	}
+	h.counts[sort.SearchFloat64s(buckets, value)]++
+	h.count++
+	h.sum += value
+}
+
+// statusClass 获取状态码分类，如 2xx
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// classifyError 获取请求失败的错误类型
+func classifyError(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCertErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorTypeCircuitOpen
+	case errors.Is(err, fasthttp.ErrBodyTooLarge):
+		return ErrorTypeBodyTooBig
+	case errors.As(err, &dnsErr):
+		return ErrorTypeDNS
+	case errors.Is(err, fasthttp.ErrTimeout), errors.Is(err, fasthttp.ErrDialTimeout),
+		errors.Is(err, fasthttp.ErrTLSHandshakeTimeout), errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTypeTimeout
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &certErr),
+		errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr):
+		return ErrorTypeTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorTypeRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, fasthttp.ErrConnectionClosed), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorTypeReset
+	}
+	return ErrorTypeOther
+}
+
+// metricsHost 获取请求的主机，使用连接路由时为 URL 中的主机
+func metricsHost(req *fasthttp.Request) string {
+	host := string(req.URI().Host())
+	if _, _, _, routed := parseRouteAddr(host); routed {
+		return string(req.Header.Host())
+	}
+	return host
+}
+
+// RequestCount 按主机、请求方法与状态码分类统计的请求数
+type RequestCount struct {
+	Host   string
+	Method string
+	Status string // 状态码分类，如 2xx，请求失败时为 error
+	Count  uint64
+}
+
+// ErrorCount 按主机、请求方法与错误类型统计的失败请求数
+type ErrorCount struct {
+	Host   string
+	Method string
+	Type   string // 错误类型，如 timeout、dns
+	Count  uint64
+}
+
+// Histogram 直方图快照
+type Histogram struct {
+	Buckets []float64 // 桶的上界，不包括 +Inf
+	Counts  []uint64  // 小于等于对应上界的累计数量，最后一个为 +Inf
+	Count   uint64    // 总数
+	Sum     float64   // 总和
+}
+
+// Mean 平均值
+func (h *Histogram) Mean() float64 {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// Quantile 根据桶估算分位数（0-1），采用线性插值，落在 +Inf 桶时返回最大的上界
+func (h *Histogram) Quantile(q float64) float64 {
+	if h == nil || h.Count == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(h.Count)
+	for i, count := range h.Counts {
+		if float64(count) < rank {
+			continue
+		}
+		if i == len(h.Buckets) {
+			return h.Buckets[len(h.Buckets)-1]
+		}
+		lower, prev := 0.0, uint64(0)
+		if i > 0 {
+			lower, prev = h.Buckets[i-1], h.Counts[i-1]
+		}
+		if count == prev {
+			return h.Buckets[i]
+		}
+		return lower + (h.Buckets[i]-lower)*(rank-float64(prev))/float64(count-prev)
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+func (h *histogram) snapshot(buckets []float64) *Histogram {
+	snap := &Histogram{Buckets: buckets, Counts: make([]uint64, len(h.counts)), Count: h.count, Sum: h.sum}
+	var total uint64
+	for i, count := range h.counts {
+		total += count
+		snap.Counts[i] = total
+	}
+	return snap
+}
+
+// MetricsSnapshot 指标快照，直方图、进行中的请求数以及重试、重定向次数均按主机区分
+type MetricsSnapshot struct {
+	Requests  []RequestCount
+	Errors    []ErrorCount
+	Latency   map[string]*Histogram // 耗时，单位为秒
+	Size      map[string]*Histogram // 响应体大小，单位为字节
+	InFlight  map[string]int64
+	Retries   map[string]uint64
+	Redirects map[string]uint64
+}
+
+// Snapshot 获取当前指标的快照
+func (m *Metrics) Snapshot() *MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := &MetricsSnapshot{
+		Latency:   make(map[string]*Histogram, len(m.latency)),
+		Size:      make(map[string]*Histogram, len(m.size)),
+		InFlight:  make(map[string]int64, len(m.inFlight)),
+		Retries:   make(map[string]uint64, len(m.retries)),
+		Redirects: make(map[string]uint64, len(m.redirects)),
+	}
+	for labels, count := range m.requests {
+		snap.Requests = append(snap.Requests, RequestCount{labels.host, labels.method, labels.status, count})
+	}
+	sort.Slice(snap.Requests, func(i, j int) bool {
+		a, b := snap.Requests[i], snap.Requests[j]
+		return a.Host+"\x00"+a.Method+"\x00"+a.Status < b.Host+"\x00"+b.Method+"\x00"+b.Status
+	})
+	for labels, count := range m.errors {
+		snap.Errors = append(snap.Errors, ErrorCount{labels.host, labels.method, labels.errorType, count})
+	}
+	sort.Slice(snap.Errors, func(i, j int) bool {
+		a, b := snap.Errors[i], snap.Errors[j]
+		return a.Host+"\x00"+a.Method+"\x00"+a.Type < b.Host+"\x00"+b.Method+"\x00"+b.Type
+	})
+	for host, h := range m.latency {
+		snap.Latency[host] = h.snapshot(m.latencyBuckets)
+	}
+	for host, h := range m.size {
+		snap.Size[host] = h.snapshot(m.sizeBuckets)
+	}
+	for host, n := range m.inFlight {
+		snap.InFlight[host] = n
+	}
+	for host, n := range m.retries {
+		snap.Retries[host] = n
+	}
+	for host, n := range m.redirects {
+		snap.Redirects[host] = n
+	}
+	return snap
+}
+
+// Total 请求总数
+func (s *MetricsSnapshot) Total() uint64 {
+	var total uint64
+	for _, c := range s.Requests {
+		total += c.Count
+	}
+	return total
+}
+
+// Failed 失败的请求数
+func (s *MetricsSnapshot) Failed() uint64 {
+	var total uint64
+	for _, c := range s.Errors {
+		total += c.Count
+	}
+	return total
+}
+
+// String 生成便于阅读的统计摘要，适合在扫描结束时输出
+func (s *MetricsSnapshot) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests: %d, failed: %d\n", s.Total(), s.Failed())
+	for _, host := range snapshotHosts(s.Latency, s.Errors) {
+		var total, failed uint64
+		var statuses []string
+		for _, c := range s.Requests {
+			if c.Host == host {
+				total += c.Count
+				if c.Status != "error" {
+					statuses = append(statuses, fmt.Sprintf("%s %s=%d", c.Method, c.Status, c.Count))
+				}
+			}
+		}
+		var errs []string
+		for _, c := range s.Errors {
+			if c.Host == host {
+				failed += c.Count
+				errs = append(errs, fmt.Sprintf("%s=%d", c.Type, c.Count))
+			}
+		}
+		fmt.Fprintf(&b, "%s: requests=%d failed=%d", host, total, failed)
+		if len(statuses) > 0 {
+			fmt.Fprintf(&b, " status[%s]", strings.Join(statuses, " "))
+		}
+		if len(errs) > 0 {
+			fmt.Fprintf(&b, " errors[%s]", strings.Join(errs, " "))
+		}
+		if h := s.Latency[host]; h != nil {
+			fmt.Fprintf(&b, " latency(mean=%s p50=%s p99=%s)", seconds(h.Mean()), seconds(h.Quantile(0.5)), seconds(h.Quantile(0.99)))
+		}
+		if h := s.Size[host]; h != nil {
+			fmt.Fprintf(&b, " size(mean=%.0fB)", h.Mean())
+		}
+		if n := s.Retries[host]; n > 0 {
+			fmt.Fprintf(&b, " retries=%d", n)
+		}
+		if n := s.Redirects[host]; n > 0 {
+			fmt.Fprintf(&b, " redirects=%d", n)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// snapshotHosts 获取有请求记录的所有主机
+func snapshotHosts(latency map[string]*Histogram, errs []ErrorCount) []string {
+	seen := make(map[string]bool)
+	for host := range latency {
+		seen[host] = true
+	}
+	for _, c := range errs {
+		seen[c.Host] = true
+	}
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func seconds(v float64) time.Duration {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return time.Duration(v * float64(time.Second)).Round(time.Microsecond)
+}
+
+// Handler 以 Prometheus 文本格式输出指标的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus 以 Prometheus 文本格式写入所有指标
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+	bw := bufio.NewWriter(w)
+
+	writeMetricHeader(bw, "httpx_requests_total", "counter", "Total number of HTTP requests by host, method and status class.")
+	for _, c := range s.Requests {
+		fmt.Fprintf(bw, "httpx_requests_total{host=%s,method=%s,status=%s} %d\n",
+			quoteLabel(c.Host), quoteLabel(c.Method), quoteLabel(c.Status), c.Count)
+	}
+
+	writeMetricHeader(bw, "httpx_request_errors_total", "counter", "Total number of failed HTTP requests by host, method and error type.")
+	for _, c := range s.Errors {
+		fmt.Fprintf(bw, "httpx_request_errors_total{host=%s,method=%s,type=%s} %d\n",
+			quoteLabel(c.Host), quoteLabel(c.Method), quoteLabel(c.Type), c.Count)
+	}
+
+	writeHistograms(bw, "httpx_request_duration_seconds", "HTTP request latency in seconds.", s.Latency)
+	writeHistograms(bw, "httpx_response_size_bytes", "HTTP response body size in bytes.", s.Size)
+
+	writeMetricHeader(bw, "httpx_requests_in_flight", "gauge", "Number of HTTP requests currently in flight.")
+	for _, host := range sortedMapKeys(s.InFlight) {
+		fmt.Fprintf(bw, "httpx_requests_in_flight{host=%s} %d\n", quoteLabel(host), s.InFlight[host])
+	}
+
+	writeMetricHeader(bw, "httpx_retries_total", "counter", "Total number of HTTP request retries.")
+	for _, host := range sortedMapKeys(s.Retries) {
+		fmt.Fprintf(bw, "httpx_retries_total{host=%s} %d\n", quoteLabel(host), s.Retries[host])
+	}
+
+	writeMetricHeader(bw, "httpx_redirects_total", "counter", "Total number of followed HTTP redirects.")
+	for _, host := range sortedMapKeys(s.Redirects) {
+		fmt.Fprintf(bw, "httpx_redirects_total{host=%s} %d\n", quoteLabel(host), s.Redirects[host])
+	}
+	return bw.Flush()
+}
+
+func writeMetricHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeHistograms(w io.Writer, name, help string, histograms map[string]*Histogram) {
+	writeMetricHeader(w, name, "histogram", help)
+	for _, host := range sortedMapKeys(histograms) {
+		h := histograms[host]
+		label := quoteLabel(host)
+		for i, bound := range h.Buckets {
+			fmt.Fprintf(w, "%s_bucket{host=%s,le=\"%s\"} %d\n", name, label, formatFloat(bound), h.Counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{host=%s,le=\"+Inf\"} %d\n", name, label, h.Count)
+		fmt.Fprintf(w, "%s_sum{host=%s} %s\n", name, label, formatFloat(h.Sum))
+		fmt.Fprintf(w, "%s_count{host=%s} %d\n", name, label, h.Count)
+	}
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteLabel 按 Prometheus 文本格式转义标签值
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
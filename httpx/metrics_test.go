@@ -0,0 +1,214 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+		}
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	metrics := NewMetrics()
+	client := NewClient().SetMetrics(metrics)
+	if client.Metrics() != metrics {
+		t.Fatal("metrics not set")
+	}
+	for _, path := range []string{"/ok", "/redirect", "/missing"} {
+		if _, err := client.R().AllowRedirect().Get(server.URL + path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refused := NewClient().SetMetrics(metrics).SetDial(func(addr string) (net.Conn, error) {
+		return nil, fmt.Errorf("dial %s failed: %w", addr, syscall.ECONNREFUSED)
+	})
+	if _, err := refused.R().Post("http://refused.local/"); err == nil {
+		t.Fatal("expected dial error")
+	}
+
+	snap := metrics.Snapshot()
+	expected := []RequestCount{
+		{host, MethodGet, "2xx", 2},
+		{host, MethodGet, "3xx", 1},
+		{host, MethodGet, "4xx", 1},
+		{"refused.local", MethodPost, "error", 1},
+	}
+	if fmt.Sprint(snap.Requests) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected requests: %v", snap.Requests)
+	}
+	if len(snap.Errors) != 1 || snap.Errors[0] != (ErrorCount{"refused.local", MethodPost, ErrorTypeRefused, 1}) {
+		t.Fatalf("unexpected errors: %v", snap.Errors)
+	}
+	if snap.Total() != 5 || snap.Failed() != 1 {
+		t.Fatalf("unexpected total %d failed %d", snap.Total(), snap.Failed())
+	}
+	if snap.Redirects[host] != 1 || snap.InFlight[host] != 0 {
+		t.Fatalf("unexpected redirects %v in flight %v", snap.Redirects, snap.InFlight)
+	}
+	if h := snap.Latency[host]; h == nil || h.Count != 4 || h.Counts[len(h.Counts)-1] != 4 {
+		t.Fatalf("unexpected latency histogram: %+v", h)
+	}
+	// 两次 2048 字节，重定向与 404 响应体较小
+	if h := snap.Size[host]; h == nil || h.Count != 4 || h.Counts[1] != 2 || h.Counts[2] != 4 {
+		t.Fatalf("unexpected size histogram: %+v", h)
+	}
+	if summary := snap.String(); !strings.Contains(summary, "requests: 5, failed: 1") ||
+		!strings.Contains(summary, "errors[connection_refused=1]") {
+		t.Fatalf("unexpected summary: %s", summary)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	for _, line := range []string{
+		"# TYPE httpx_requests_total counter",
+		fmt.Sprintf(`httpx_requests_total{host="%s",method="GET",status="2xx"} 2`, host),
+		`httpx_request_errors_total{host="refused.local",method="POST",type="connection_refused"} 1`,
+		"# TYPE httpx_request_duration_seconds histogram",
+		fmt.Sprintf(`httpx_request_duration_seconds_bucket{host="%s",le="+Inf"} 4`, host),
+		fmt.Sprintf(`httpx_response_size_bytes_bucket{host="%s",le="4096"} 4`, host),
+		fmt.Sprintf(`httpx_response_size_bytes_count{host="%s"} 4`, host),
+		fmt.Sprintf(`httpx_requests_in_flight{host="%s"} 0`, host),
+		fmt.Sprintf(`httpx_redirects_total{host="%s"} 1`, host),
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Fatalf("metric line %q not found in:\n%s", line, body)
+		}
+	}
+
+	metrics.Reset()
+	if snap = metrics.Snapshot(); snap.Total() != 0 || len(snap.Latency) != 0 {
+		t.Fatalf("metrics not reset: %+v", snap)
+	}
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	metrics := NewMetrics()
+	client := NewClient().SetMetrics(metrics)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = client.R().Get(server.URL + "/")
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for metrics.Snapshot().InFlight[host] != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("request not in flight")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	<-done
+	if n := metrics.Snapshot().InFlight[host]; n != 0 {
+		t.Fatalf("expected 0 in flight, got %d", n)
+	}
+}
+
+func TestMetricsMaxHosts(t *testing.T) {
+	metrics := NewMetrics().SetMaxHosts(2)
+	for _, host := range []string{"a", "b", "c", "d", "a"} {
+		metrics.begin(host)
+		metrics.done(host, MethodGet, 200, 1, time.Millisecond, nil)
+	}
+	metrics.AddRetry("e")
+	snap := metrics.Snapshot()
+	if len(snap.Latency) != 3 || snap.Latency["a"].Count != 2 || snap.Latency[MetricsOtherHost].Count != 2 ||
+		snap.Retries[MetricsOtherHost] != 1 {
+		t.Fatalf("unexpected hosts: %v", snap)
+	}
+
+	// 重置后进行中的请求仍按原标签结束
+	metrics.begin("c")
+	metrics.Reset()
+	metrics.SetMaxHosts(0)
+	metrics.done("c", MethodGet, 200, 1, time.Millisecond, nil)
+	if snap = metrics.Snapshot(); snap.InFlight[MetricsOtherHost] != 0 || snap.Latency[MetricsOtherHost].Count != 1 {
+		t.Fatalf("unexpected in flight: %v", snap.InFlight)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for negative max hosts")
+		}
+	}()
+	metrics.SetMaxHosts(-1)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&CircuitOpenError{Host: "a"}, ErrorTypeCircuitOpen},
+		{fmt.Errorf("get: %w", context.DeadlineExceeded), ErrorTypeTimeout},
+		{&net.DNSError{Err: "no such host", Name: "a"}, ErrorTypeDNS},
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), ErrorTypeRefused},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), ErrorTypeReset},
+		{fmt.Errorf("handshake: %w", tls.AlertError(40)), ErrorTypeTLS},
+		{errors.New("lookup a.local failed: no such host"), ErrorTypeOther},
+		{errors.New("unexpected"), ErrorTypeOther},
+	}
+	for _, test := range tests {
+		if actual := classifyError(test.err); actual != test.expected {
+			t.Errorf("classifyError(%v) = %s, expected %s", test.err, actual, test.expected)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	metrics := NewMetrics().SetSizeBuckets(10, 20, 40)
+	for _, size := range []int{5, 15, 15, 30, 100} {
+		metrics.begin("a")
+		metrics.done("a", MethodGet, 200, size, time.Millisecond, nil)
+	}
+	h := metrics.Snapshot().Size["a"]
+	if fmt.Sprint(h.Counts) != "[1 3 4 5]" || h.Sum != 165 {
+		t.Fatalf("unexpected histogram: %+v", h)
+	}
+	if q := h.Quantile(0.5); q != 17.5 {
+		t.Fatalf("unexpected median %v", q)
+	}
+	if q := h.Quantile(1); q != 40 {
+		t.Fatalf("unexpected max %v", q)
+	}
+	if !math.IsNaN(new(Histogram).Quantile(0.5)) {
+		t.Fatal("empty histogram quantile should be NaN")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for unordered buckets")
+		}
+	}()
+	metrics.SetLatencyBuckets(1, 1)
+}
@@ -0,0 +1,119 @@
+package httpx
+
+import (
+	"github.com/valyala/fasthttp"
+	"sort"
+	"strings"
+)
+
+// 有序请求头模式下，所有请求头（包括 Host、User-Agent、Content-Type、Cookie 等）都作为普通请求头保存，
+// 按添加顺序与原始大小写发送，不再由 fasthttp 调整位置
+
+// enableOrderedHeader 将请求头切换为有序模式，已有的请求头按当前顺序保留
+func enableOrderedHeader(h *fasthttp.RequestHeader) {
+	var pairs [][2]string
+	for key, value := range h.All() {
+		pairs = append(pairs, [2]string{string(key), string(value)})
+	}
+	resetOrderedHeader(h, pairs)
+}
+
+// resetOrderedHeader 使用给定的请求头重置有序模式的请求头
+func resetOrderedHeader(h *fasthttp.RequestHeader, pairs [][2]string) {
+	method, requestURI := string(h.Method()), string(h.RequestURI())
+	h.Reset()
+	h.SetMethod(method)
+	h.SetRequestURI(requestURI)
+	markOrderedHeader(h)
+	// 提前完成 Cookie 收集，避免之后遍历请求头时 Cookie 被移出普通请求头而不再发送
+	h.Cookie("")
+	for _, pair := range pairs {
+		h.Add(pair[0], pair[1])
+	}
+}
+
+// markOrderedHeader 关闭特殊请求头处理与请求头名称规范化，`CopyTo` 不会复制特殊请求头设置，复制后需要重新调用
+func markOrderedHeader(h *fasthttp.RequestHeader) {
+	h.DisableSpecialHeader()
+	h.DisableNormalizing()
+}
+
+// orderedHeaderPairs 按顺序获取有序模式的所有请求头，
+// 有序模式下 `All` 会重复返回 Host 等请求头，因此从序列化结果中解析
+func orderedHeaderPairs(h *fasthttp.RequestHeader) [][2]string {
+	lines := strings.Split(string(h.Header()), "\r\n")
+	var pairs [][2]string
+	for _, line := range lines[1:] {
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			pairs = append(pairs, [2]string{key, value})
+		}
+	}
+	return pairs
+}
+
+// setHeaderPair 忽略大小写设置请求头，已存在时在第一次出现的位置替换并删除其余同名请求头，不存在时追加到末尾
+func setHeaderPair(pairs [][2]string, key, value string) [][2]string {
+	found := false
+	result := pairs[:0]
+	for _, pair := range pairs {
+		if !strings.EqualFold(pair[0], key) {
+			result = append(result, pair)
+		} else if !found {
+			found = true
+			result = append(result, [2]string{pair[0], value})
+		}
+	}
+	if !found {
+		result = append(result, [2]string{key, value})
+	}
+	return result
+}
+
+// delHeaderPair 忽略大小写删除请求头
+func delHeaderPair(pairs [][2]string, key string) [][2]string {
+	result := pairs[:0]
+	for _, pair := range pairs {
+		if !strings.EqualFold(pair[0], key) {
+			result = append(result, pair)
+		}
+	}
+	return result
+}
+
+// lookupHeaderPair 忽略大小写获取请求头第一次出现的值
+func lookupHeaderPair(pairs [][2]string, key string) (string, bool) {
+	for _, pair := range pairs {
+		if strings.EqualFold(pair[0], key) {
+			return pair[1], true
+		}
+	}
+	return "", false
+}
+
+// setOrderedHeader 有序模式下设置请求头
+func setOrderedHeader(h *fasthttp.RequestHeader, key, value string) {
+	resetOrderedHeader(h, setHeaderPair(orderedHeaderPairs(h), key, value))
+}
+
+// delOrderedHeader 有序模式下删除请求头
+func delOrderedHeader(h *fasthttp.RequestHeader, key string) {
+	resetOrderedHeader(h, delHeaderPair(orderedHeaderPairs(h), key))
+}
+
+// cookieHeader 将 Cookie 按名称排序后拼接为 Cookie 请求头，existing 为已有的 Cookie 请求头
+func cookieHeader(existing string, cookies map[string]string) string {
+	keys := make([]string, 0, len(cookies))
+	for key := range cookies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys)+1)
+	if existing != "" {
+		values = append(values, existing)
+	}
+	for _, key := range keys {
+		values = append(values, key+"="+cookies[key])
+	}
+	return strings.Join(values, "; ")
+}
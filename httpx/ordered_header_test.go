@@ -0,0 +1,131 @@
+package httpx
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newRawServer 启动一个记录原始请求报文的 HTTP 服务，每个请求的报文（不含请求体）依次发送到返回的 channel
+func newRawServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	requests := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					var raw strings.Builder
+					length := 0
+					for {
+						line, err := reader.ReadString('\n')
+						if err != nil {
+							return
+						}
+						raw.WriteString(line)
+						if key, value, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && strings.EqualFold(key, "Content-Length") {
+							length, _ = strconv.Atoi(strings.TrimSpace(value))
+						}
+						if line == "\r\n" {
+							break
+						}
+					}
+					if _, err := io.CopyN(io.Discard, reader, int64(length)); err != nil {
+						return
+					}
+					requests <- raw.String()
+					conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), requests
+}
+
+// headerLines 获取原始请求报文中的请求头行
+func headerLines(raw string) []string {
+	head, _, _ := strings.Cut(raw, "\r\n\r\n")
+	lines := strings.Split(head, "\r\n")
+	return lines[1:]
+}
+
+func TestRequestAddHeader(t *testing.T) {
+	addr, requests := newRawServer(t)
+
+	req := NewClient().R().
+		SetHeader("X-Forwarded-For", "1.1.1.1").
+		AddHeader("X-Forwarded-For", "2.2.2.2")
+	if _, err := req.Get("http://" + addr + "/"); err != nil {
+		t.Fatal(err)
+	}
+	raw := <-requests
+	if strings.Count(raw, "X-Forwarded-For:") != 2 {
+		t.Fatalf("duplicate headers not sent:\n%s", raw)
+	}
+	if !strings.Contains(req.String(), "X-Forwarded-For: 2.2.2.2") {
+		t.Fatalf("duplicate headers not in String():\n%s", req.String())
+	}
+}
+
+func TestRequestOrderedHeaders(t *testing.T) {
+	addr, requests := newRawServer(t)
+
+	req := NewClient().
+		SetCommonHeader("X-Common", "1").
+		R().
+		SetOrderedHeaders(true).
+		SetHeader("accept", "*/*").
+		AddHeader("X-Dup", "1").
+		SetHeader("Host", addr).
+		AddHeader("X-Dup", "2").
+		SetHeader("Accept", "text/html").
+		SetUserAgent("ua").
+		SetCookie("b", "2").
+		SetCookie("a", "1").
+		SetHeader("X-Removed", "1").
+		DelHeader("x-removed").
+		SetContentType("text/plain").
+		SetBody([]byte("body"))
+	if _, err := req.Post("http://" + addr + "/"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"X-Common: 1",
+		"accept: text/html",
+		"X-Dup: 1",
+		"Host: " + addr,
+		"X-Dup: 2",
+		"User-Agent: ua",
+		"Content-Type: text/plain",
+		"Cookie: a=1; b=2",
+		"Content-Length: 4",
+	}
+	if lines := headerLines(<-requests); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected header order:\n%s", strings.Join(lines, "\n"))
+	}
+	if lines := headerLines(req.String()); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected String():\n%s", req.String())
+	}
+
+	// 未设置 Host 时放在第一个
+	if _, err := NewClient().SetOrderedHeaders(true).R().SetHeader("X-A", "1").Get("http://" + addr + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if lines := headerLines(<-requests); len(lines) != 2 || lines[0] != "Host: "+addr || lines[1] != "X-A: 1" {
+		t.Fatalf("unexpected headers: %v", lines)
+	}
+}
@@ -0,0 +1,387 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"iter"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpsPorts 默认优先使用 HTTPS 探测的端口
+var httpsPorts = map[int]bool{443: true, 4443: true, 8443: true, 9443: true, 10443: true}
+
+// plainHTTPToHTTPS 向 HTTPS 端口发送 HTTP 请求时常见的响应内容
+var plainHTTPToHTTPS = []string{
+	"The plain HTTP request was sent to HTTPS port",
+	"Client sent an HTTP request to an HTTPS server",
+	"This combination of host and port requires TLS",
+	"speaking plain HTTP to an SSL-enabled server port",
+}
+
+// errPlainHTTPToHTTPS 向 HTTPS 端口发送了 HTTP 请求
+var errPlainHTTPToHTTPS = errors.New("plain HTTP request sent to HTTPS port")
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// ProbeOptions 存活探测配置
+type ProbeOptions struct {
+	Client          *Client            // 发送请求使用的客户端，代理、超时、单主机连接数等配置均生效，默认使用 NewClient()
+	Concurrency     int                // 同时探测的目标数，默认为 50
+	Method          string             // 请求方法，默认为 GET
+	Path            string             // 请求路径，默认为 /
+	Schemes         []string           // 探测的协议，默认根据端口决定优先顺序，优先协议失败时再尝试另一个协议
+	AllSchemes      bool               // HTTP 与 HTTPS 都进行探测并分别返回结果，默认只返回第一个成功的协议
+	FollowRedirects bool               // 跟随重定向
+	MaxRedirects    int                // 最大重定向次数，默认为 5
+	OnResult        func(*ProbeResult) // 每发现一个存活服务时回调，可用于流式输出，各回调串行执行
+}
+
+// ProbeResult 存活的 Web 服务
+type ProbeResult struct {
+	Target        string        // 探测目标 host:port
+	Scheme        string        // 协议
+	URL           string        // 探测的地址
+	FinalURL      string        // 跟随重定向后的最终地址，未跟随重定向时与 URL 一致
+	Status        int           // 状态码
+	Title         string        // 页面标题
+	Server        string        // Server 响应头
+	ContentLength int           // 响应体长度
+	Location      string        // 重定向地址
+	TLS           *ProbeTLS     // TLS 信息，HTTP 服务为 nil
+	Timing        *Timing       // 请求耗时
+	Duration      time.Duration // 探测该服务的总耗时，包括尝试其他协议
+}
+
+// ProbeTLS 探测到的 TLS 信息
+type ProbeTLS struct {
+	Version     string    // TLS 版本
+	CipherSuite string    // 加密套件
+	ALPN        string    // 协商的应用层协议
+	Subject     string    // 证书主题的 CommonName
+	Issuer      string    // 证书签发者的 CommonName
+	DNSNames    []string  // 证书中的域名
+	NotAfter    time.Time // 证书过期时间
+}
+
+func (r *ProbeResult) String() string {
+	return fmt.Sprintf("%s [%d] [%s] [%s] [%d]", r.FinalURL, r.Status, r.Title, r.Server, r.ContentLength)
+}
+
+// probeJob 单个探测任务
+type probeJob struct {
+	index   int
+	host    string
+	port    int
+	schemes []string
+	path    string
+}
+
+// Probe 探测目标中存活的 HTTP/HTTPS 服务，targets 支持：
+//   - IP 或域名，与 ports 中的每个端口组合，ports 为空时使用 80、443
+//   - CIDR，网段内的每个地址与 ports 组合，探测过程中按需展开
+//   - host:port，只探测指定端口
+//   - URL，只使用 URL 中的协议、端口与路径进行探测
+//
+// 结果按目标的输入顺序返回，ctx 取消时停止探测并返回已有结果与 ctx 的错误
+func Probe(ctx context.Context, targets []string, ports []int, opts *ProbeOptions) ([]*ProbeResult, error) {
+	if opts == nil {
+		opts = &ProbeOptions{}
+	}
+	client := opts.Client
+	if client == nil {
+		client = NewClient()
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 50
+	}
+	if len(ports) == 0 {
+		ports = []int{80, 443}
+	}
+
+	jobs, err := probeJobs(targets, ports, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results []*ProbeResult
+		indexes = make(map[*ProbeResult]int)
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		ch      = make(chan *probeJob)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ch {
+				for _, result := range probe(ctx, client, job, opts) {
+					mu.Lock()
+					results = append(results, result)
+					indexes[result] = job.index
+					if opts.OnResult != nil {
+						opts.OnResult(result)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+loop:
+	for job := range jobs {
+		select {
+		case <-ctx.Done():
+			break loop
+		case ch <- job:
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return indexes[results[i]] < indexes[results[j]]
+	})
+	return results, ctx.Err()
+}
+
+// probeTarget 解析后的探测目标，prefix 有效时为网段内的所有地址
+type probeTarget struct {
+	host    string
+	prefix  netip.Prefix
+	ports   []int
+	schemes []string
+	path    string
+}
+
+// probeJobs 解析探测目标，返回按需展开的探测任务，避免大网段在探测开始前全部展开到内存中
+func probeJobs(targets []string, ports []int, opts *ProbeOptions) (iter.Seq[*probeJob], error) {
+	path := opts.Path
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var parsed []probeTarget
+	for _, target := range targets {
+		target = strings.TrimSpace(target)
+		switch {
+		case target == "":
+		case strings.Contains(target, "://"):
+			u, err := url.Parse(target)
+			if err != nil || u.Hostname() == "" {
+				return nil, fmt.Errorf("invalid target: %s", target)
+			}
+			port, _ := strconv.Atoi(u.Port())
+			if port == 0 {
+				port = 80
+				if u.Scheme == "https" {
+					port = 443
+				}
+			}
+			targetPath := u.RequestURI()
+			if u.Path == "" && u.RawQuery == "" {
+				targetPath = path
+			}
+			parsed = append(parsed, probeTarget{host: u.Hostname(), ports: []int{port}, schemes: []string{u.Scheme}, path: targetPath})
+		case strings.Contains(target, "/"):
+			prefix, err := netip.ParsePrefix(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cidr: %s", target)
+			}
+			parsed = append(parsed, probeTarget{prefix: prefix.Masked(), ports: ports, path: path})
+		default:
+			if host, portStr, err := net.SplitHostPort(target); err == nil {
+				port, err := strconv.Atoi(portStr)
+				if err != nil {
+					return nil, fmt.Errorf("invalid target: %s", target)
+				}
+				parsed = append(parsed, probeTarget{host: host, ports: []int{port}, path: path})
+				continue
+			}
+			parsed = append(parsed, probeTarget{host: strings.Trim(target, "[]"), ports: ports, path: path})
+		}
+	}
+
+	return func(yield func(*probeJob) bool) {
+		index := 0
+		add := func(target *probeTarget, host string) bool {
+			for _, port := range target.ports {
+				schemes := target.schemes
+				if len(schemes) == 0 {
+					schemes = opts.Schemes
+				}
+				if len(schemes) == 0 {
+					schemes = []string{"http", "https"}
+					if httpsPorts[port] {
+						schemes = []string{"https", "http"}
+					}
+				}
+				if !yield(&probeJob{index: index, host: host, port: port, schemes: schemes, path: target.path}) {
+					return false
+				}
+				index++
+			}
+			return true
+		}
+		for i := range parsed {
+			target := &parsed[i]
+			if !target.prefix.IsValid() {
+				if !add(target, target.host) {
+					return
+				}
+				continue
+			}
+			for addr := target.prefix.Addr(); target.prefix.Contains(addr); addr = addr.Next() {
+				if !add(target, addr.String()) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// probe 按顺序尝试各个协议，向 HTTPS 端口发送 HTTP 请求时改用 HTTPS
+func probe(ctx context.Context, client *Client, job *probeJob, opts *ProbeOptions) []*ProbeResult {
+	start := time.Now()
+	hostPort := net.JoinHostPort(job.host, strconv.Itoa(job.port))
+
+	var results []*ProbeResult
+	tried := make(map[string]bool)
+	schemes := append([]string(nil), job.schemes...)
+	for i := 0; i < len(schemes); i++ {
+		scheme := schemes[i]
+		if tried[scheme] || ctx.Err() != nil {
+			continue
+		}
+		tried[scheme] = true
+
+		result, err := probeURL(ctx, client, scheme+"://"+hostPort+job.path, opts)
+		if errors.Is(err, errPlainHTTPToHTTPS) {
+			schemes = append(schemes, "https")
+			continue
+		} else if err != nil {
+			continue
+		}
+
+		result.Target = hostPort
+		result.Duration = time.Since(start)
+		results = append(results, result)
+		if !opts.AllSchemes {
+			break
+		}
+	}
+	return results
+}
+
+// probeURL 请求探测地址并生成探测结果，ctx 取消时立即返回 ctx 的错误
+func probeURL(ctx context.Context, client *Client, target string, opts *ProbeOptions) (*ProbeResult, error) {
+	req := client.R()
+	if opts.FollowRedirects {
+		maxRedirects := opts.MaxRedirects
+		if maxRedirects <= 0 {
+			maxRedirects = 5
+		}
+		req.AllowRedirect().SetMaxRedirectsCount(maxRedirects)
+	}
+	method := opts.Method
+	if method == "" {
+		method = MethodGet
+	}
+
+	// fasthttp 不支持取消进行中的请求，ctx 取消时不再等待，请求在超时后自行结束
+	type response struct {
+		resp *Response
+		err  error
+	}
+	done := make(chan response, 1)
+	go func() {
+		resp, err := req.Do(target, method)
+		done <- response{resp, err}
+	}()
+	var resp *Response
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		resp = r.resp
+	}
+	if resp.Status() == 400 && strings.HasPrefix(target, "http:") && isPlainHTTPToHTTPS(resp.Body()) {
+		return nil, errPlainHTTPToHTTPS
+	}
+
+	u, _ := url.Parse(target)
+	result := &ProbeResult{
+		Scheme:        u.Scheme,
+		URL:           target,
+		FinalURL:      resp.URL(),
+		Status:        resp.Status(),
+		Title:         extractTitle(resp.Body()),
+		ContentLength: resp.ContentLength(),
+		Location:      resp.Location(),
+		Timing:        resp.Timing(),
+		TLS:           newProbeTLS(resp.TLS()),
+	}
+	for key, value := range resp.OriginalResponse.Header.All() {
+		if strings.EqualFold(string(key), "Server") {
+			result.Server = string(value)
+			break
+		}
+	}
+	return result, nil
+}
+
+// isPlainHTTPToHTTPS 判断是否为向 HTTPS 端口发送 HTTP 请求的错误响应
+func isPlainHTTPToHTTPS(body []byte) bool {
+	for _, text := range plainHTTPToHTTPS {
+		if bytes.Contains(body, []byte(text)) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractTitle 提取页面标题
+func extractTitle(body []byte) string {
+	match := titleRegexp.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+}
+
+func newProbeTLS(state *tls.ConnectionState) *ProbeTLS {
+	if state == nil {
+		return nil
+	}
+	info := &ProbeTLS{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+	}
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		info.Subject = cert.Subject.CommonName
+		info.Issuer = cert.Issuer.CommonName
+		info.DNSNames = cert.DNSNames
+		info.NotAfter = cert.NotAfter
+	}
+	return info
+}
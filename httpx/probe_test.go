@@ -0,0 +1,145 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "probe-test")
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("<html><title>\n  Home &amp; Away </title></html>"))
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	_ = closed.Close()
+
+	port := func(s *httptest.Server) int {
+		return s.Listener.Addr().(*net.TCPAddr).Port
+	}
+	var streamed int
+	results, err := Probe(context.Background(), []string{"127.0.0.1/32"}, []int{port(plain), port(secure), closedPort},
+		&ProbeOptions{FollowRedirects: true, OnResult: func(*ProbeResult) { streamed++ }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || streamed != 2 {
+		t.Fatalf("expected 2 live services, got %v", results)
+	}
+
+	httpResult, httpsResult := results[0], results[1]
+	if httpResult.Scheme != "http" || httpResult.Status != 200 || httpResult.Title != "Home & Away" ||
+		httpResult.Server != "probe-test" || httpResult.FinalURL != plain.URL+"/home" || httpResult.TLS != nil {
+		t.Errorf("unexpected http result: %+v", httpResult)
+	}
+	// 先尝试 HTTP，收到 HTTPS 端口的错误响应后改用 HTTPS
+	if httpsResult.Scheme != "https" || httpsResult.Status != 200 || httpsResult.TLS == nil ||
+		httpsResult.TLS.Version == "" || httpsResult.Target != "127.0.0.1:"+strconv.Itoa(port(secure)) {
+		t.Errorf("unexpected https result: %+v", httpsResult)
+	}
+
+	// 不跟随重定向
+	results, err = Probe(context.Background(), []string{plain.URL}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != 302 || results[0].Location != "/home" {
+		t.Fatalf("unexpected results: %v", results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = Probe(ctx, []string{"127.0.0.1"}, []int{port(plain)}, nil); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestProbeCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results, err := Probe(ctx, []string{server.URL}, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || len(results) != 0 {
+		t.Fatalf("unexpected results: %v, %v", results, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probe should stop when ctx is done, took %s", elapsed)
+	}
+}
+
+func TestProbeJobs(t *testing.T) {
+	jobs, err := probeJobs([]string{"example.com", "10.0.0.1:8443", "https://a.com/x?y=1", "192.168.1.0/31"}, []int{80, 443},
+		&ProbeOptions{Path: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"http,https example.com:80 /admin",
+		"https,http example.com:443 /admin",
+		"https,http 10.0.0.1:8443 /admin",
+		"https a.com:443 /x?y=1",
+		"http,https 192.168.1.0:80 /admin",
+		"https,http 192.168.1.0:443 /admin",
+		"http,https 192.168.1.1:80 /admin",
+		"https,http 192.168.1.1:443 /admin",
+	}
+	var got []string
+	for job := range jobs {
+		if job.index != len(got) {
+			t.Fatalf("unexpected job index %d", job.index)
+		}
+		s := job.schemes[0]
+		if len(job.schemes) > 1 {
+			s += "," + job.schemes[1]
+		}
+		got = append(got, s+" "+net.JoinHostPort(job.host, strconv.Itoa(job.port))+" "+job.path)
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected jobs:\n%s", strings.Join(got, "\n"))
+	}
+
+	// 大网段按需展开，提前停止时不再生成后续任务
+	jobs, err = probeJobs([]string{"10.0.0.0/8"}, []int{80, 443}, &ProbeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last *probeJob
+	for job := range jobs {
+		if last = job; job.index == 2 {
+			break
+		}
+	}
+	if last.host != "10.0.0.1" || last.port != 80 {
+		t.Fatalf("unexpected job: %+v", last)
+	}
+
+	if _, err = probeJobs([]string{"10.0.0.0/33"}, nil, &ProbeOptions{}); err == nil {
+		t.Error("expected invalid cidr error")
+	}
+}
@@ -0,0 +1,176 @@
+package httpx
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// BrowserProfile 浏览器请求特征，包含 UA 以及与之匹配的请求头。
+// 不包含 Accept-Encoding，避免收到压缩的响应体
+type BrowserProfile struct {
+	Name      string      // 名称，如 chrome、firefox
+	UserAgent string      // UA
+	Headers   [][2]string // 浏览器导航请求发送的请求头（包括 User-Agent），按浏览器实际的发送顺序排列
+}
+
+// 内置的浏览器特征
+var (
+	ProfileChrome        = newChromeProfile("chrome", ChromeUserAgent, "Google Chrome", "140", "Windows", false)
+	ProfileEdge          = newChromeProfile("edge", EdgeUserAgent, "Microsoft Edge", "140", "Windows", false)
+	ProfileFirefox       = newFirefoxProfile("firefox", FirefoxUserAgent)
+	ProfileSafari        = newSafariProfile("safari", SafariUserAgent)
+	ProfileChromeAndroid = newChromeProfile("chrome-android", AndroidUserAgent, "Google Chrome", "140", "Android", true)
+	ProfileSafariIPhone  = newSafariProfile("safari-iphone", IPhoneSafariUserAgent)
+
+	// Profiles 所有内置的浏览器特征
+	Profiles = []*BrowserProfile{
+		ProfileChrome, ProfileEdge, ProfileFirefox, ProfileSafari, ProfileChromeAndroid, ProfileSafariIPhone,
+	}
+)
+
+// chromiumVersionRegexp 匹配 Chromium 内核浏览器的主版本号
+var chromiumVersionRegexp = regexp.MustCompile(`(?:Chrome|CriOS)/(\d+)`)
+
+// edgeVersionRegexp 匹配 Edge 的主版本号
+var edgeVersionRegexp = regexp.MustCompile(`Edg(?:A|iOS)?/(\d+)`)
+
+func newChromeProfile(name, ua, brand, version, platform string, mobile bool) *BrowserProfile {
+	mobileFlag := "?0"
+	if mobile {
+		mobileFlag = "?1"
+	}
+	return &BrowserProfile{
+		Name:      name,
+		UserAgent: ua,
+		Headers: [][2]string{
+			{"sec-ch-ua", fmt.Sprintf(`"Chromium";v="%s", "Not=A?Brand";v="24", "%s";v="%s"`, version, brand, version)},
+			{"sec-ch-ua-mobile", mobileFlag},
+			{"sec-ch-ua-platform", `"` + platform + `"`},
+			{"Upgrade-Insecure-Requests", "1"},
+			{"User-Agent", ua},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"},
+			{"Sec-Fetch-Site", "none"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"Sec-Fetch-User", "?1"},
+			{"Sec-Fetch-Dest", "document"},
+			{"Accept-Language", "en-US,en;q=0.9"},
+			{"Priority", "u=0, i"},
+		},
+	}
+}
+
+func newFirefoxProfile(name, ua string) *BrowserProfile {
+	return &BrowserProfile{
+		Name:      name,
+		UserAgent: ua,
+		Headers: [][2]string{
+			{"User-Agent", ua},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			{"Accept-Language", "en-US,en;q=0.5"},
+			{"Upgrade-Insecure-Requests", "1"},
+			{"Sec-Fetch-Dest", "document"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"Sec-Fetch-Site", "none"},
+			{"Sec-Fetch-User", "?1"},
+			{"Priority", "u=0, i"},
+		},
+	}
+}
+
+func newSafariProfile(name, ua string) *BrowserProfile {
+	return &BrowserProfile{
+		Name:      name,
+		UserAgent: ua,
+		Headers: [][2]string{
+			{"Sec-Fetch-Dest", "document"},
+			{"User-Agent", ua},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			{"Sec-Fetch-Site", "none"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"Accept-Language", "en-US,en;q=0.9"},
+			{"Priority", "u=0, i"},
+		},
+	}
+}
+
+// NewProfile 根据 UA 识别浏览器类型，生成与之匹配的浏览器特征，无法识别时只包含通用的 Accept 请求头
+func NewProfile(ua string) *BrowserProfile {
+	mobile := strings.Contains(ua, "Mobile")
+	switch {
+	case strings.Contains(ua, "Firefox/"):
+		return newFirefoxProfile("firefox", ua)
+	case edgeVersionRegexp.MatchString(ua):
+		version := edgeVersionRegexp.FindStringSubmatch(ua)[1]
+		return newChromeProfile("edge", ua, "Microsoft Edge", version, uaPlatform(ua), mobile)
+	case chromiumVersionRegexp.MatchString(ua) && !strings.Contains(ua, "CriOS/"):
+		version := chromiumVersionRegexp.FindStringSubmatch(ua)[1]
+		return newChromeProfile("chrome", ua, "Google Chrome", version, uaPlatform(ua), mobile)
+	case strings.Contains(ua, "Safari/"):
+		// iOS 上的浏览器均使用 Safari 内核
+		return newSafariProfile("safari", ua)
+	}
+	return &BrowserProfile{
+		Name:      "custom",
+		UserAgent: ua,
+		Headers: [][2]string{
+			{"User-Agent", ua},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			{"Accept-Language", "en-US,en;q=0.9"},
+		},
+	}
+}
+
+// uaPlatform 获取 Sec-CH-UA-Platform 对应的平台名称
+func uaPlatform(ua string) string {
+	switch {
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return "Unknown"
+}
+
+// LoadProfiles 从文件中加载 UA 列表并生成浏览器特征，每行一个 UA，忽略空行与 # 开头的注释
+func LoadProfiles(path string) ([]*BrowserProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open user agent file failed: %v", err)
+	}
+	defer f.Close()
+
+	var profiles []*BrowserProfile
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		profiles = append(profiles, NewProfile(line))
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read user agent file failed: %v", err)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no user agent found in %s", path)
+	}
+	return profiles, nil
+}
+
+// randomProfile 随机选择一个浏览器特征
+func randomProfile(profiles []*BrowserProfile) *BrowserProfile {
+	if len(profiles) == 0 {
+		return nil
+	}
+	return profiles[rand.IntN(len(profiles))]
+}
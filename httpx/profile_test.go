@@ -0,0 +1,120 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewProfile(t *testing.T) {
+	tests := []struct {
+		ua       string
+		name     string
+		platform string
+		mobile   string
+	}{
+		{ChromeUserAgent, "chrome", `"Windows"`, "?0"},
+		{EdgeUserAgent, "edge", `"Windows"`, "?0"},
+		{AndroidUserAgent, "chrome", `"Android"`, "?1"},
+		{FirefoxUserAgent, "firefox", "", ""},
+		{SafariUserAgent, "safari", "", ""},
+		{IPhoneSafariUserAgent, "safari", "", ""},
+		{"curl/8.0", "custom", "", ""},
+	}
+	for _, tt := range tests {
+		p := NewProfile(tt.ua)
+		if p.Name != tt.name || p.UserAgent != tt.ua {
+			t.Fatalf("%s: unexpected profile %s", tt.ua, p.Name)
+		}
+		headers := map[string]string{}
+		for _, h := range p.Headers {
+			headers[h[0]] = h[1]
+		}
+		if headers["User-Agent"] != tt.ua {
+			t.Fatalf("%s: profile headers should contain user agent", tt.ua)
+		}
+		if headers["sec-ch-ua-platform"] != tt.platform || headers["sec-ch-ua-mobile"] != tt.mobile {
+			t.Fatalf("%s: unexpected client hints %v", tt.ua, headers)
+		}
+	}
+
+	if ua := NewProfile(EdgeUserAgent).Headers[0][1]; !strings.Contains(ua, `"Microsoft Edge";v="140"`) {
+		t.Fatalf("unexpected sec-ch-ua: %s", ua)
+	}
+}
+
+func TestRequestProfile(t *testing.T) {
+	var last *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+	}))
+	defer server.Close()
+
+	client := NewClient().SetCommonHeader("Accept-Language", "zh-CN")
+	resp, err := client.R().SetProfile(ProfileChrome).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.UserAgent() != ChromeUserAgent || last.Header.Get("Sec-Fetch-Mode") != "navigate" ||
+		last.Header.Get("Sec-Ch-Ua-Platform") != `"Windows"` {
+		t.Fatalf("profile headers not sent: %v", last.Header)
+	}
+
+	// 请求头（包括 User-Agent）按浏览器的顺序与大小写发送
+	raw := resp.OriginalRequest.Header.String()
+	pos := -1
+	for _, header := range ProfileChrome.Headers {
+		i := strings.Index(raw, "\r\n"+header[0]+": ")
+		if i <= pos {
+			t.Fatalf("unexpected position of %s:\n%s", header[0], raw)
+		}
+		pos = i
+	}
+
+	// 默认请求头优先于浏览器特征
+	client.SetProfile(ProfileFirefox)
+	if _, err = client.R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if last.UserAgent() != FirefoxUserAgent || last.Header.Get("Accept-Language") != "zh-CN" {
+		t.Fatalf("unexpected headers: %v", last.Header)
+	}
+}
+
+func TestClientRandomProfile(t *testing.T) {
+	seen := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen[r.UserAgent()] = true
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "ua.txt")
+	content := "# user agents\n" + ChromeUserAgent + "\n\n" + FirefoxUserAgent + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 {
+		t.Fatalf("expected 2 profiles, got %d", len(profiles))
+	}
+
+	client := NewClient().SetRandomProfile(profiles...)
+	for i := 0; i < 50 && len(seen) < 2; i++ {
+		if _, err = client.R().Get(server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(seen) != 2 || !seen[ChromeUserAgent] || !seen[FirefoxUserAgent] {
+		t.Fatalf("profiles not rotated: %v", seen)
+	}
+
+	if _, err = LoadProfiles(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
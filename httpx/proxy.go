@@ -0,0 +1,323 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/kelesec/gopkg/iputils"
+	"github.com/kelesec/proxyclient"
+	"github.com/valyala/fasthttp"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ProxySelector 按目标地址（host:port）选择代理，返回 nil 时直接连接，返回错误时请求失败
+type ProxySelector func(host string) (*url.URL, error)
+
+// proxyRouter 按目标地址选择代理并建立连接
+type proxyRouter struct {
+	selector func(addr string, isTLS bool) (*url.URL, error)
+
+	mu    sync.Mutex
+	dials map[string]proxyclient.Dial // 按代理地址缓存的代理连接器
+}
+
+func newProxyRouter(selector func(addr string, isTLS bool) (*url.URL, error)) *proxyRouter {
+	return &proxyRouter{selector: selector, dials: make(map[string]proxyclient.Dial)}
+}
+
+// dial 建立到 addr 的连接，未选择代理时使用 direct，host 为原始请求的 host:port。
+// 明文 HTTP 请求使用 http 代理时直接连接代理并以绝对形式发送请求（与 curl 一致），tunnel 为 true 时（如 WebSocket）
+// 以及其他情况通过 CONNECT 或 SOCKS 建立隧道
+func (r *proxyRouter) dial(host, addr string, isTLS, tunnel bool, direct fasthttp.DialFunc) (net.Conn, error) {
+	proxyURL, err := r.selector(addr, isTLS)
+	if err != nil {
+		return nil, fmt.Errorf("select proxy for %s failed: %w", addr, err)
+	}
+	if proxyURL == nil {
+		return direct(addr)
+	}
+	if !isTLS && !tunnel && proxyURL.Scheme == "http" {
+		return dialForward(host, proxyURL, direct)
+	}
+
+	key := proxyURL.String()
+	r.mu.Lock()
+	dial, ok := r.dials[key]
+	if !ok {
+		if dial, err = proxyclient.NewClient(proxyURL); err != nil {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("create proxy client for %s failed: %w", proxyURL.Redacted(), err)
+		}
+		r.dials[key] = dial
+	}
+	r.mu.Unlock()
+
+	conn, err := dial.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s via proxy %s failed: %w", addr, proxyURL.Redacted(), err)
+	}
+	return conn, nil
+}
+
+// dialForward 连接 HTTP 代理，通过 forwardConn 转发明文 HTTP 请求
+func dialForward(host string, proxyURL *url.URL, direct fasthttp.DialFunc) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := direct(proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial proxy %s failed: %w", proxyURL.Redacted(), err)
+	}
+
+	fc := &forwardConn{Conn: conn, origin: "http://" + host}
+	if h, port, err := net.SplitHostPort(host); err == nil && port == "80" {
+		fc.origin = "http://" + h
+		if strings.Contains(h, ":") {
+			fc.origin = "http://[" + h + "]"
+		}
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		fc.auth = "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	return fc, nil
+}
+
+// forwardConn 通过 HTTP 代理转发明文 HTTP 请求的连接，将请求行改写为绝对形式（如 GET http://example.com/ HTTP/1.1），
+// 并添加 Proxy-Authorization 请求头。与 traceConn 相同，每次调用 RemoteAddr 视为开始发送新的请求
+type forwardConn struct {
+	net.Conn
+	origin string // 添加到请求路径之前的 scheme 与 host
+	auth   string // Proxy-Authorization 请求头，为空时不添加
+
+	mu    sync.Mutex
+	state forwardState
+}
+
+// forwardState 改写请求行的进度，请求行可能被拆分到多次写入中
+type forwardState int
+
+const (
+	forwardIdle   forwardState = iota // 无需改写
+	forwardMethod                     // 等待请求方法之后的空格
+	forwardTarget                     // 等待请求路径
+	forwardLine                       // 等待请求行结束，之后添加 Proxy-Authorization
+)
+
+func (c *forwardConn) RemoteAddr() net.Addr {
+	c.mu.Lock()
+	c.state = forwardMethod
+	c.mu.Unlock()
+	return c.Conn.RemoteAddr()
+}
+
+func (c *forwardConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	out := c.rewrite(b)
+	c.mu.Unlock()
+
+	n, err := c.Conn.Write(out)
+	if err != nil {
+		// 无法准确对应到 b 中的位置，按照已写入的字节数估算
+		return min(n, len(b)), err
+	}
+	return len(b), nil
+}
+
+// rewrite 改写请求行，需持有锁
+func (c *forwardConn) rewrite(b []byte) []byte {
+	if c.state == forwardIdle {
+		return b
+	}
+	out := make([]byte, 0, len(b)+len(c.origin)+len(c.auth))
+	for len(b) > 0 && c.state != forwardIdle {
+		switch c.state {
+		case forwardMethod:
+			i := bytes.IndexByte(b, ' ')
+			if i < 0 {
+				return append(out, b...)
+			}
+			out, b = append(out, b[:i+1]...), b[i+1:]
+			c.state = forwardTarget
+		case forwardTarget:
+			// 只改写 origin-form 的请求路径，如 OPTIONS * 保持不变
+			if b[0] == '/' {
+				out = append(out, c.origin...)
+			}
+			c.state = forwardLine
+			if c.auth == "" {
+				c.state = forwardIdle
+			}
+		case forwardLine:
+			i := bytes.IndexByte(b, '\n')
+			if i < 0 {
+				return append(out, b...)
+			}
+			out, b = append(out, b[:i+1]...), b[i+1:]
+			out = append(out, c.auth...)
+			c.state = forwardIdle
+		}
+	}
+	return append(out, b...)
+}
+
+// proxyEnv 从环境变量中读取的代理配置
+type proxyEnv struct {
+	httpProxy  *url.URL // HTTP 请求使用的代理
+	httpsProxy *url.URL // HTTPS 请求使用的代理
+	noProxy    *noProxy
+}
+
+// getEnvAny 获取第一个非空的环境变量
+func getEnvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// loadProxyEnv 读取 HTTP_PROXY、HTTPS_PROXY、ALL_PROXY 与 NO_PROXY（大写优先，其次为小写），
+// HTTP_PROXY、HTTPS_PROXY 未设置时使用 ALL_PROXY。
+// 设置了 REQUEST_METHOD 时处于 CGI 环境，HTTP_PROXY 可能来自请求头 Proxy（httpoxy），因此忽略 HTTP_PROXY
+func loadProxyEnv() (*proxyEnv, error) {
+	allProxy, err := parseProxyURL(getEnvAny("ALL_PROXY", "all_proxy"))
+	if err != nil {
+		return nil, err
+	}
+	env := &proxyEnv{
+		httpProxy:  allProxy,
+		httpsProxy: allProxy,
+		noProxy:    parseNoProxy(getEnvAny("NO_PROXY", "no_proxy")),
+	}
+	if value := getEnvAny("HTTP_PROXY", "http_proxy"); value != "" && os.Getenv("REQUEST_METHOD") == "" {
+		if env.httpProxy, err = parseProxyURL(value); err != nil {
+			return nil, err
+		}
+	}
+	if value := getEnvAny("HTTPS_PROXY", "https_proxy"); value != "" {
+		if env.httpsProxy, err = parseProxyURL(value); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// parseProxyURL 解析代理地址，未指定协议时默认为 http
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url: %s", proxy)
+	}
+	return proxyURL, nil
+}
+
+// proxy 选择目标地址使用的代理
+func (e *proxyEnv) proxy(addr string, isTLS bool) (*url.URL, error) {
+	if e.noProxy.match(addr) {
+		return nil, nil
+	}
+	if isTLS {
+		return e.httpsProxy, nil
+	}
+	return e.httpProxy, nil
+}
+
+// noProxyRule NO_PROXY 中的一项
+type noProxyRule struct {
+	domain string // 域名，同时匹配其子域名
+	ip     net.IP
+	ipNet  *net.IPNet // CIDR，解析时完成转换
+	port   string     // 为空时匹配所有端口
+}
+
+// noProxy 不使用代理的目标地址列表
+type noProxy struct {
+	all   bool
+	rules []noProxyRule
+}
+
+// parseNoProxy 解析 NO_PROXY，各项以逗号或空白分隔，支持：
+//   - *，所有地址均不使用代理
+//   - 域名，如 example.com、.example.com、*.example.com，均匹配该域名及其子域名
+//   - IP，如 192.168.1.1、::1、[::1]
+//   - CIDR，如 10.0.0.0/8、fd00::/8，通过 `iputils.Contains` 匹配
+//   - 以上的域名、IP 可以带端口，如 example.com:8080、[::1]:8080，此时仅匹配该端口
+func parseNoProxy(value string) *noProxy {
+	n := &noProxy{}
+	entries := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, entry := range entries {
+		if entry == "*" {
+			n.all = true
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			n.rules = append(n.rules, noProxyRule{ipNet: ipNet})
+			continue
+		}
+		var rule noProxyRule
+		host := strings.Trim(entry, "[]")
+		if net.ParseIP(host) == nil {
+			if h, port, err := net.SplitHostPort(entry); err == nil {
+				host, rule.port = h, port
+			}
+		}
+		if rule.ip = net.ParseIP(host); rule.ip == nil {
+			host = strings.TrimPrefix(host, "*")
+			rule.domain = strings.TrimSuffix(strings.TrimPrefix(host, "."), ".")
+			if rule.domain == "" {
+				continue
+			}
+		}
+		n.rules = append(n.rules, rule)
+	}
+	return n
+}
+
+// match 判断目标地址（host:port）是否不使用代理
+func (n *noProxy) match(addr string) bool {
+	if n.all {
+		return true
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	ip := net.ParseIP(host)
+	for _, rule := range n.rules {
+		if rule.port != "" && rule.port != port {
+			continue
+		}
+		switch {
+		case rule.ipNet != nil:
+			if ip != nil {
+				if ok, _ := iputils.Contains(rule.ipNet, host); ok {
+					return true
+				}
+			}
+		case rule.ip != nil:
+			if rule.ip.Equal(ip) {
+				return true
+			}
+		case host == rule.domain || strings.HasSuffix(host, "."+rule.domain):
+			return true
+		}
+	}
+	return false
+}
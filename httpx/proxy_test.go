@@ -0,0 +1,257 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// testProxy 测试用的 HTTP 代理，支持 CONNECT 隧道与绝对形式请求的转发
+type testProxy struct {
+	*httptest.Server
+	tunnels  atomic.Int64 // 建立过的隧道数
+	forwards atomic.Int64 // 转发过的请求数
+	auth     atomic.Value // 最近一次转发请求的 Proxy-Authorization
+}
+
+func newTestProxy(t *testing.T) *testProxy {
+	p := &testProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			if !r.URL.IsAbs() {
+				http.Error(w, "absolute url required", http.StatusBadRequest)
+				return
+			}
+			p.forwards.Add(1)
+			p.auth.Store(r.Header.Get("Proxy-Authorization"))
+			r.RequestURI = ""
+			r.Header.Del("Proxy-Authorization")
+			resp, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			_, _ = io.Copy(w, resp.Body)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = target.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		p.tunnels.Add(1)
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		_, _ = io.Copy(conn, target)
+		_ = conn.Close()
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestClientProxyFromEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	proxy := newTestProxy(t)
+
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "all_proxy", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("http_proxy", "user:pass@"+strings.TrimPrefix(proxy.URL, "http://"))
+
+	// 明文 HTTP 请求以绝对形式发送给代理，而不是建立隧道
+	client := NewClient().SetProxyFromEnvironment()
+	for i := 0; i < 2; i++ {
+		resp, err := client.R().Get(server.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Body()) != "hello" {
+			t.Fatalf("unexpected response: %q", resp.Body())
+		}
+	}
+	// 基于 net/http 的传输层同样生效
+	resp, err := NewClient().SetTransport(NewHttpTransport()).SetProxyFromEnvironment().R().Get(server.URL + "/")
+	if err != nil || string(resp.Body()) != "hello" {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+	if proxy.forwards.Load() != 3 || proxy.tunnels.Load() != 0 || proxy.auth.Load() != "Basic dXNlcjpwYXNz" {
+		t.Fatalf("request should be forwarded by proxy: %d forwards, %d tunnels, auth %v",
+			proxy.forwards.Load(), proxy.tunnels.Load(), proxy.auth.Load())
+	}
+
+	// NO_PROXY 命中时直接连接
+	t.Setenv("NO_PROXY", "example.com, 127.0.0.0/8")
+	client.SetProxyFromEnvironment()
+	client.fastClient.CloseIdleConnections()
+	if _, err := client.R().Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if proxy.forwards.Load() != 3 {
+		t.Fatalf("request should bypass proxy, %d forwards", proxy.forwards.Load())
+	}
+
+	t.Setenv("HTTP_PROXY", "://invalid")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic with invalid proxy url")
+		}
+	}()
+	client.SetProxyFromEnvironment()
+}
+
+func TestClientProxySelector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	proxy := newTestProxy(t)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	var hosts []string
+	client := NewClient().SetProxySelector(func(host string) (*url.URL, error) {
+		hosts = append(hosts, host)
+		if host == server.Listener.Addr().String() {
+			return proxyURL, nil
+		}
+		return nil, errors.New("blocked")
+	})
+	if _, err := client.R().Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if proxy.forwards.Load() != 1 || len(hosts) != 1 || hosts[0] != server.Listener.Addr().String() {
+		t.Fatalf("unexpected selection: %v, %d forwards", hosts, proxy.forwards.Load())
+	}
+
+	if _, err := client.R().Get("http://127.0.0.1:1/"); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Fatalf("expected selector error, got %v", err)
+	}
+
+	// 取消后直接连接
+	client.SetProxySelector(nil)
+	client.fastClient.CloseIdleConnections()
+	if _, err := client.R().Get(server.URL + "/"); err != nil || proxy.forwards.Load() != 1 {
+		t.Fatalf("request should not go through proxy: %v, %d forwards", err, proxy.forwards.Load())
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "all_proxy", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("ALL_PROXY", "socks5://127.0.0.1:1080")
+	t.Setenv("https_proxy", "127.0.0.1:8443")
+	t.Setenv("no_proxy", "internal.example.com")
+
+	env, err := loadProxyEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addr  string
+		isTLS bool
+		want  string
+	}{
+		{"example.com:80", false, "socks5://127.0.0.1:1080"},
+		{"example.com:443", true, "http://127.0.0.1:8443"},
+		{"api.internal.example.com:443", true, ""},
+	}
+	for _, tt := range tests {
+		proxyURL, err := env.proxy(tt.addr, tt.isTLS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if proxyURL != nil {
+			got = proxyURL.String()
+		}
+		if got != tt.want {
+			t.Fatalf("proxy for %s: got %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	// CGI 环境中忽略 HTTP_PROXY（httpoxy）
+	t.Setenv("HTTP_PROXY", "127.0.0.1:8080")
+	t.Setenv("REQUEST_METHOD", "GET")
+	if env, err = loadProxyEnv(); err != nil || env.httpProxy.String() != "socks5://127.0.0.1:1080" {
+		t.Fatalf("HTTP_PROXY should be ignored in cgi: %v, %v", env, err)
+	}
+}
+
+func TestForwardConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &forwardConn{Conn: client, origin: "http://example.com", auth: "Proxy-Authorization: Basic eA==\r\n"}
+	received := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(server)
+		received <- string(data)
+	}()
+
+	// 请求行被拆分到多次写入中，请求体不会被改写
+	conn.RemoteAddr()
+	for _, chunk := range []string{"GE", "T ", "/a?b=1 HTTP/1.1\r", "\nHost: example.com\r\n\r\n", "GET / HTTP/1.1"} {
+		if n, err := conn.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("write %q: %d, %v", chunk, n, err)
+		}
+	}
+	conn.RemoteAddr()
+	_, _ = conn.Write([]byte("OPTIONS * HTTP/1.1\r\n\r\n"))
+	_ = client.Close()
+
+	expected := "GET http://example.com/a?b=1 HTTP/1.1\r\nProxy-Authorization: Basic eA==\r\nHost: example.com\r\n\r\nGET / HTTP/1.1" +
+		"OPTIONS * HTTP/1.1\r\nProxy-Authorization: Basic eA==\r\n\r\n"
+	if actual := <-received; actual != expected {
+		t.Fatalf("unexpected request:\n%q\nexpected:\n%q", actual, expected)
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	n := parseNoProxy(".example.com,*.corp.local internal, 192.168.1.10 10.0.0.0/8,fd00::/8,[::1], localhost:8080")
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"example.com:443", true},
+		{"api.example.com:443", true},
+		{"badexample.com:443", false},
+		{"git.corp.local:22", true},
+		{"internal:80", true},
+		{"internal.net:80", false},
+		{"192.168.1.10:80", true},
+		{"192.168.1.11:80", false},
+		{"10.20.30.40:443", true},
+		{"[fd12::1]:443", true},
+		{"[::1]:80", true},
+		{"localhost:8080", true},
+		{"localhost:80", false},
+		{"API.EXAMPLE.COM.:443", true},
+	}
+	for _, tt := range tests {
+		if got := n.match(tt.addr); got != tt.want {
+			t.Fatalf("match(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	if !parseNoProxy("*").match("anything:80") || parseNoProxy("").match("example.com:80") {
+		t.Fatal("unexpected wildcard matching")
+	}
+}
@@ -0,0 +1,92 @@
+package httpx
+
+import (
+	_url "net/url"
+	"sort"
+	"strings"
+)
+
+// queryParam 原始查询模式下按顺序发送的 URL 请求参数
+type queryParam struct {
+	key     string
+	value   string
+	noValue bool // 只有参数名，没有 = 与值，如 ?debug
+	raw     bool // 参数名与值不进行转义
+}
+
+func (p queryParam) encode() string {
+	key, value := p.key, p.value
+	if !p.raw {
+		key, value = _url.QueryEscape(key), _url.QueryEscape(value)
+	}
+	if p.noValue {
+		return key
+	}
+	return key + "=" + value
+}
+
+// encodeQueryParams 按顺序拼接 URL 请求参数
+func encodeQueryParams(params []queryParam) string {
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		parts = append(parts, p.encode())
+	}
+	return strings.Join(parts, "&")
+}
+
+// valuesToQueryParams 将 `url.Values` 按参数名排序后转换为有序的 URL 请求参数
+func valuesToQueryParams(values _url.Values) []queryParam {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []queryParam
+	for _, key := range keys {
+		for _, value := range values[key] {
+			params = append(params, queryParam{key: key, value: value})
+		}
+	}
+	return params
+}
+
+// setQueryParam 设置 URL 请求参数，已存在时在第一次出现的位置替换并删除其余同名参数，不存在时追加到末尾
+func setQueryParam(params []queryParam, param queryParam) []queryParam {
+	found := false
+	result := params[:0]
+	for _, p := range params {
+		if p.key != param.key {
+			result = append(result, p)
+		} else if !found {
+			found = true
+			result = append(result, param)
+		}
+	}
+	if !found {
+		result = append(result, param)
+	}
+	return result
+}
+
+// delQueryParam 删除所有同名的 URL 请求参数
+func delQueryParam(params []queryParam, key string) []queryParam {
+	result := params[:0]
+	for _, p := range params {
+		if p.key != key {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// joinQuery 使用 & 拼接查询字符串，忽略空的部分
+func joinQuery(queries ...string) string {
+	parts := make([]string, 0, len(queries))
+	for _, query := range queries {
+		if query != "" {
+			parts = append(parts, query)
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,53 @@
+package httpx
+
+import (
+	"strings"
+	"testing"
+)
+
+// requestLine 获取原始请求报文的请求行
+func requestLine(raw string) string {
+	line, _, _ := strings.Cut(raw, "\r\n")
+	return line
+}
+
+func TestRequestRawQuery(t *testing.T) {
+	addr, requests := newRawServer(t)
+	client := NewClient().SetCommonQueryParam("token", "t")
+
+	// 默认模式下参数会被解码、排序并重新转义
+	if _, err := client.R().Get("http://" + addr + "/?b=%2527&a"); err != nil {
+		t.Fatal(err)
+	}
+	if line := requestLine(<-requests); line != "GET /?a=&b=%2527&token=t HTTP/1.1" {
+		t.Fatalf("unexpected request line: %s", line)
+	}
+
+	req := client.R().
+		SetRawQuery(true).
+		AddQueryKey("a").
+		AddQueryParam("a", "1 2").
+		AddRawQueryParam("p", "%252e%252e/'").
+		SetQueryParam("z", "1").
+		AddQueryParam("z", "2").
+		SetQueryParam("token", "new")
+	if _, err := req.Get("http://" + addr + "/x?q=%zz&q=<script>&&flag"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "GET /x?q=%zz&q=<script>&&flag&token=new&a&a=1+2&p=%252e%252e/'&z=1&z=2 HTTP/1.1"
+	if line := requestLine(<-requests); line != expected {
+		t.Fatalf("unexpected request line:\n%s\n%s", line, expected)
+	}
+	if line := requestLine(req.String()); !strings.Contains(line, "/x?q=%zz&q=<script>&&flag&token=new") {
+		t.Fatalf("raw query not in String(): %s", line)
+	}
+
+	// 关闭后恢复默认模式，有序参数合并回 QueryParam
+	req.SetRawQuery(false).DelQueryParam("a").DelQueryParam("p")
+	if _, err := req.Get("http://" + addr + "/y"); err != nil {
+		t.Fatal(err)
+	}
+	if line := requestLine(<-requests); line != "GET /y?token=new&z=1&z=2 HTTP/1.1" {
+		t.Fatalf("unexpected request line: %s", line)
+	}
+}
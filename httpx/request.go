@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/valyala/fasthttp"
+	"maps"
 	_url "net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -32,12 +37,22 @@ type Request struct {
 	allowSaveResponseHistory bool // 不保存重定向请求历史响应信息
 	maxRedirectsCount        int  // 最大重定向请求次数，默认为5
 
+	noCache        bool              // 跳过响应缓存
+	connectAddress string            // 实际建立连接的地址，为空时连接 URL 中的主机
+	sni            string            // TLS 握手时使用的 SNI，为空时使用 URL 中的域名
+	unixSocket     string            // 通过 Unix socket 建立连接，为空时使用 TCP
+	baseURL        string            // 基础 URL，继承自 Client，请求地址为相对路径时拼接在其后
+	pathParams     map[string]string // URL 路径参数，值已完成转义
+	orderedHeaders bool              // 有序请求头模式，请求头按添加顺序原样发送
+	rawQuery       bool              // 原始查询模式，URL 中的查询字符串原样发送
+	queryParams    []queryParam      // 原始查询模式下按顺序发送的 URL 请求参数
+
 	// 加个锁
 	clock *sync.Mutex
 }
 
 func newRequest(client *Client) *Request {
-	return &Request{
+	r := &Request{
 		client:                   client,
 		clock:                    &sync.Mutex{},
 		allowRedirect:            false,
@@ -45,21 +60,120 @@ func newRequest(client *Client) *Request {
 		maxRedirectsCount:        5,
 		QueryParam:               _url.Values{},
 	}
+
+	// 继承 Client 的会话级别默认配置
+	client.clock.Lock()
+	defer client.clock.Unlock()
+	r.baseURL = client.baseURL
+	if client.orderedHeaders {
+		r.orderedHeaders = true
+		enableOrderedHeader(&r.Headers)
+	}
+	if profile := randomProfile(client.profiles); profile != nil {
+		r.applyProfile(profile)
+	}
+	// 默认请求头优先于浏览器特征
+	for k, v := range client.commonHeaders.All() {
+		r.setHeader(string(k), string(v))
+	}
+	for k, v := range client.commonCookies {
+		r.SetCookie(k, v)
+	}
+	for k, vs := range client.commonQueryParams {
+		r.QueryParam[k] = append([]string(nil), vs...)
+	}
+	if client.commonAuth != nil {
+		auth := *client.commonAuth
+		r.BasicAuth = &auth
+	}
+	return r
+}
+
+// resolveUrl 请求地址为相对路径时拼接在基础 URL 之后
+func (r *Request) resolveUrl(url string) string {
+	if r.baseURL == "" || hasScheme(url) {
+		return url
+	}
+	if url == "" || strings.HasPrefix(url, "?") {
+		return r.baseURL + url
+	}
+	return r.baseURL + "/" + strings.TrimLeft(url, "/")
+}
+
+// hasScheme 判断地址是否以协议开头（如 http://、http+unix://），查询参数中的 :// 不影响判断
+func hasScheme(url string) bool {
+	i := strings.Index(url, "://")
+	if i <= 0 {
+		return false
+	}
+	for j, c := range url[:i] {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case j > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// pathParamRegexp 匹配 URL 路径中的 {name} 占位符
+var pathParamRegexp = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// replacePathParams 替换 URL 路径中的 {name} 占位符，存在未设置的路径参数时返回错误
+func (r *Request) replacePathParams(url string) (string, error) {
+	end := strings.IndexAny(url, "?#")
+	if end < 0 {
+		end = len(url)
+	}
+
+	var missing []string
+	path := pathParamRegexp.ReplaceAllStringFunc(url[:end], func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if value, ok := r.pathParams[name]; ok {
+			return value
+		}
+		missing = append(missing, name)
+		return placeholder
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unresolved path params: %s", strings.Join(missing, ", "))
+	}
+	return path + url[end:], nil
 }
 
 // parseUrl 解析URL
 func (r *Request) parseUrl(url string) error {
+	url, err := r.replacePathParams(url)
+	if err != nil {
+		return err
+	}
+	url, socket, err := parseUnixURL(url)
+	if err != nil {
+		return err
+	}
+	if socket != "" {
+		r.unixSocket = socket
+	}
+
 	u, err := _url.Parse(url)
 	if err != nil {
 		return fmt.Errorf("parse uri failed: %s", err)
 	}
 
-	for k, vs := range u.Query() {
-		for _, v := range vs {
-			r.QueryParam.Add(k, v)
+	if r.rawQuery {
+		// URL 中的查询字符串不解码、不排序，之后依次拼接有序参数与 QueryParam 中的参数
+		u.RawQuery = joinQuery(u.RawQuery, encodeQueryParams(r.queryParams), r.QueryParam.Encode())
+	} else {
+		// 合并到副本中，避免重复执行时 URL 中的参数被重复添加
+		query := cloneValues(r.QueryParam)
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				query.Add(k, v)
+			}
 		}
+		u.RawQuery = query.Encode()
 	}
-	u.RawQuery = r.QueryParam.Encode()
 
 	r.schema = u.Scheme
 	r.hostPort = u.Host
@@ -75,16 +189,24 @@ func (r *Request) preCheck(url, method string, req *fasthttp.Request) error {
 	r.clock.Lock()
 	defer r.clock.Unlock()
 
+	url = r.resolveUrl(url)
 	if url == "" {
 		return fmt.Errorf("URI is empty")
 	} else if err := r.parseUrl(url); err != nil {
 		return fmt.Errorf("parse URI %s error: %v", r.url, err)
 	}
 
+	r.Method = method
+	if r.orderedHeaders {
+		r.preCheckOrdered(method, req)
+		req.CopyTo(&r.OriginalRequest)
+		markOrderedHeader(&r.OriginalRequest.Header)
+		return nil
+	}
+
 	r.Headers.CopyTo(&req.Header)
 	req.SetRequestURI(r.url)
 	req.Header.SetMethod(method)
-	r.Method = method
 
 	for k, v := range r.Cookies {
 		req.Header.SetCookie(k, v)
@@ -120,14 +242,72 @@ func (r *Request) preCheck(url, method string, req *fasthttp.Request) error {
 	return nil
 }
 
+// preCheckOrdered 有序请求头模式下同步请求，Host、User-Agent、Cookie 等请求头已存在时在原位置更新，
+// 不存在时追加到末尾，其中 Host 不存在时放在第一个
+func (r *Request) preCheckOrdered(method string, req *fasthttp.Request) {
+	pairs := orderedHeaderPairs(&r.Headers)
+	if _, ok := lookupHeaderPair(pairs, "Host"); !ok {
+		pairs = append([][2]string{{"Host", r.hostPort}}, pairs...)
+	}
+	if r.UserAgent != "" {
+		pairs = setHeaderPair(pairs, "User-Agent", r.UserAgent)
+	}
+	if r.ContentType != "" {
+		pairs = setHeaderPair(pairs, "Content-Type", r.ContentType)
+	}
+	if len(r.Cookies) > 0 {
+		existing, _ := lookupHeaderPair(pairs, "Cookie")
+		pairs = setHeaderPair(pairs, "Cookie", cookieHeader(existing, r.Cookies))
+	}
+	if r.BasicAuth != nil {
+		header, auth := r.BasicAuth.GetBasicAuth()
+		pairs = setHeaderPair(pairs, header, auth)
+	}
+	if r.FormData != nil || len(r.Body) != 0 {
+		body := r.Body
+		if r.FormData != nil {
+			body = []byte(r.FormData.Encode())
+		}
+		r.ContentLength = len(body)
+		req.SetBody(body)
+		pairs = setHeaderPair(pairs, "Content-Length", strconv.Itoa(r.ContentLength))
+	}
+
+	resetOrderedHeader(&req.Header, pairs)
+	req.SetRequestURI(r.url)
+	req.Header.SetMethod(method)
+}
+
+// route 获取请求的连接路由，未设置连接地址与 SNI 时返回 nil
+func (r *Request) route() *route {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+
+	if r.connectAddress == "" && r.sni == "" && r.unixSocket == "" {
+		return nil
+	}
+	_, port := splitHostPort(r.hostPort, r.schema == "https")
+	return &route{
+		hostname: r.hostname,
+		port:     port,
+		connect:  r.connectAddress,
+		sni:      r.sni,
+		socket:   r.unixSocket,
+	}
+}
+
 // postCheck 后置检查，主要用于将 `fasthttp.Response` 属性同步给自定义的 Response
-func (r *Request) postCheck(resp *fasthttp.Response) *Response {
-	newResp := &Response{}
+func (r *Request) postCheck(resp *fasthttp.Response, ex *exchange, url string) *Response {
+	newResp := &Response{url: url, timing: ex.timing, cacheStatus: ex.cacheStatus}
 	resp.CopyTo(&newResp.OriginalResponse)
 	r.OriginalRequest.CopyTo(&newResp.OriginalRequest)
+	if r.orderedHeaders {
+		markOrderedHeader(&newResp.OriginalRequest.Header)
+	}
 
-	newResp.headerBytes = resp.Header.Header()
-	newResp.body = resp.Body()
+	// resp 会被放回对象池，响应头与响应体取自副本
+	newResp.headerBytes = newResp.OriginalResponse.Header.Header()
+	newResp.body = newResp.OriginalResponse.Body()
 	newResp.respSize = len(resp.Header.Header()) + len(resp.Body())
 	newResp.contentLength = resp.Header.ContentLength()
 	if newResp.contentLength < 0 {
@@ -153,8 +333,103 @@ func (r *Request) postCheck(resp *fasthttp.Response) *Response {
 	return newResp
 }
 
-// Do 执行HTTP请求
+// Do 执行HTTP请求，请求在当前配置的副本上执行，同一个 Request 可以被多个 goroutine 并发重复使用
 func (r *Request) Do(url, method string) (*Response, error) {
+	r.clock.Lock()
+	prepared := r.clone()
+	r.clock.Unlock()
+
+	resp, err := prepared.do(url, method)
+	r.syncPrepared(prepared)
+	return resp, err
+}
+
+// syncPrepared 同步最近一次执行的请求信息，用于 Url()、String() 等方法
+func (r *Request) syncPrepared(prepared *Request) {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.Method = prepared.Method
+	r.url = prepared.url
+	r.schema = prepared.schema
+	r.hostPort = prepared.hostPort
+	r.hostname = prepared.hostname
+	r.port = prepared.port
+	r.path = prepared.path
+	r.ContentLength = prepared.ContentLength
+	prepared.OriginalRequest.CopyTo(&r.OriginalRequest)
+	if r.orderedHeaders {
+		markOrderedHeader(&r.OriginalRequest.Header)
+	}
+}
+
+// Clone 深拷贝请求，副本与原请求互不影响，请求体与原请求共享，不应修改其内容
+func (r *Request) Clone() *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	c := r.clone()
+	r.OriginalRequest.CopyTo(&c.OriginalRequest)
+	if r.orderedHeaders {
+		markOrderedHeader(&c.OriginalRequest.Header)
+	}
+	return c
+}
+
+// clone 拷贝请求的配置，不包括 OriginalRequest，调用方需要持有锁或保证请求不会被修改
+func (r *Request) clone() *Request {
+	c := &Request{
+		Method:                   r.Method,
+		url:                      r.url,
+		schema:                   r.schema,
+		hostPort:                 r.hostPort,
+		hostname:                 r.hostname,
+		port:                     r.port,
+		path:                     r.path,
+		Cookies:                  maps.Clone(r.Cookies),
+		ContentType:              r.ContentType,
+		ContentLength:            r.ContentLength,
+		UserAgent:                r.UserAgent,
+		QueryParam:               cloneValues(r.QueryParam),
+		Body:                     r.Body,
+		client:                   r.client,
+		allowRedirect:            r.allowRedirect,
+		allowSaveResponseHistory: r.allowSaveResponseHistory,
+		maxRedirectsCount:        r.maxRedirectsCount,
+		noCache:                  r.noCache,
+		connectAddress:           r.connectAddress,
+		sni:                      r.sni,
+		unixSocket:               r.unixSocket,
+		baseURL:                  r.baseURL,
+		pathParams:               maps.Clone(r.pathParams),
+		orderedHeaders:           r.orderedHeaders,
+		rawQuery:                 r.rawQuery,
+		queryParams:              slices.Clone(r.queryParams),
+		clock:                    &sync.Mutex{},
+	}
+	if r.FormData != nil {
+		c.FormData = cloneValues(r.FormData)
+	}
+	if r.BasicAuth != nil {
+		auth := *r.BasicAuth
+		c.BasicAuth = &auth
+	}
+	r.Headers.CopyTo(&c.Headers)
+	if r.orderedHeaders {
+		markOrderedHeader(&c.Headers)
+	}
+	return c
+}
+
+// cloneValues 深拷贝 `url.Values`，nil 时返回空的 `url.Values`
+func cloneValues(values _url.Values) _url.Values {
+	c := make(_url.Values, len(values))
+	for k, vs := range values {
+		c[k] = append([]string(nil), vs...)
+	}
+	return c
+}
+
+// do 执行HTTP请求
+func (r *Request) do(url, method string) (*Response, error) {
 	if method == "" {
 		if r.Method != "" {
 			method = r.Method
@@ -174,25 +449,30 @@ func (r *Request) Do(url, method string) (*Response, error) {
 		return nil, fmt.Errorf("preCheck err: %v", err)
 	}
 
+	rt := r.route()
+	rt.apply(req)
+
+	hopUrl := r.Url()
+	noCache := r.cacheDisabled()
 	redirectCount := 0
 	finalResp := new(Response)
 	respHistory := make([]*Response, 0)
 
 	for {
-		err := r.client.execute(req, resp)
+		ex, err := r.client.execute(req, resp, noCache)
 		if err != nil {
-			return nil, fmt.Errorf("get %s err: %v", r.url, err)
+			return nil, fmt.Errorf("get %s err: %w", r.url, err)
 		}
 
 		// 不允许重定向时直接退出
 		if !r.allowRedirect {
-			return r.postCheck(resp), nil
+			return r.postCheck(resp, ex, hopUrl), nil
 		}
 
 		// 非重定向请求直接退出循环
 		statusCode := resp.Header.StatusCode()
 		if !fasthttp.StatusCodeIsRedirect(statusCode) {
-			finalResp = r.postCheck(resp)
+			finalResp = r.postCheck(resp, ex, hopUrl)
 			respHistory = append(respHistory, finalResp)
 			if len(respHistory) != 0 {
 				finalResp.responseHistory = respHistory
@@ -206,7 +486,7 @@ func (r *Request) Do(url, method string) (*Response, error) {
 			return nil, fasthttp.ErrTooManyRedirects
 		}
 
-		tmpResp := r.postCheck(resp)
+		tmpResp := r.postCheck(resp, ex, hopUrl)
 		if tmpResp.Location() == "" {
 			return nil, fasthttp.ErrMissingLocation
 		}
@@ -220,12 +500,37 @@ func (r *Request) Do(url, method string) (*Response, error) {
 		if string(req.Header.Method()) == "POST" && (statusCode == 301 || statusCode == 302) {
 			req.Header.SetMethod(MethodGet)
 		}
-		req.SetRequestURI(tmpResp.location)
+		location, err := resolveLocation(hopUrl, tmpResp.location)
+		if err != nil {
+			return nil, err
+		}
+		if metrics := r.client.Metrics(); metrics != nil {
+			metrics.addRedirect(metricsHost(req))
+		}
+		req.SetRequestURI(location)
+		if r.orderedHeaders {
+			setOrderedHeader(&req.Header, "Host", string(req.URI().Host()))
+		}
+		rt.apply(req)
+		hopUrl = location
 	}
 
 	return finalResp, nil
 }
 
+// resolveLocation 根据当前请求地址解析重定向地址，兼容相对路径
+func resolveLocation(base, location string) (string, error) {
+	u, err := _url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parse uri failed: %s", err)
+	}
+	next, err := u.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("parse location %s failed: %s", location, err)
+	}
+	return next.String(), nil
+}
+
 func (r *Request) Get(url string) (*Response, error) {
 	return r.Do(url, MethodGet)
 }
@@ -316,10 +621,18 @@ func (r *Request) Path() string {
 func (r *Request) SetHeader(key, value string) *Request {
 	r.clock.Lock()
 	defer r.clock.Unlock()
-	r.Headers.Set(key, value)
+	r.setHeader(key, value)
 	return r
 }
 
+func (r *Request) setHeader(key, value string) {
+	if r.orderedHeaders {
+		setOrderedHeader(&r.Headers, key, value)
+	} else {
+		r.Headers.Set(key, value)
+	}
+}
+
 // SetHeaders 设置请求头
 func (r *Request) SetHeaders(headers map[string]string) *Request {
 	for k, v := range headers {
@@ -328,6 +641,49 @@ func (r *Request) SetHeaders(headers map[string]string) *Request {
 	return r
 }
 
+// AddHeader 添加请求头，不会覆盖已有的同名请求头，可用于发送重复的请求头。
+// 非有序模式下 Host、User-Agent、Content-Type 等特殊请求头只会保留一个
+func (r *Request) AddHeader(key, value string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.Headers.Add(key, value)
+	return r
+}
+
+// DelHeader 删除请求头，可用于移除继承自 Client 的默认请求头
+func (r *Request) DelHeader(key string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	if r.orderedHeaders {
+		delOrderedHeader(&r.Headers, key)
+	} else {
+		r.Headers.Del(key)
+	}
+	return r
+}
+
+// SetOrderedHeaders 设置有序请求头模式，开启后所有请求头（包括 Host、User-Agent、Cookie 等）按添加顺序与原始大小写发送，
+// Host 未设置时放在第一个，已有的请求头按当前顺序保留。开启后应通过 SetHeader、AddHeader、DelHeader 修改请求头
+func (r *Request) SetOrderedHeaders(b bool) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	if b == r.orderedHeaders {
+		return r
+	}
+	r.orderedHeaders = b
+	if b {
+		enableOrderedHeader(&r.Headers)
+		return r
+	}
+
+	pairs := orderedHeaderPairs(&r.Headers)
+	r.Headers.Reset()
+	for _, pair := range pairs {
+		r.Headers.Add(pair[0], pair[1])
+	}
+	return r
+}
+
 // SetCookie 设置 Cookie
 func (r *Request) SetCookie(key, value string) *Request {
 	r.clock.Lock()
@@ -347,6 +703,14 @@ func (r *Request) SetCookies(cookies map[string]string) *Request {
 	return r
 }
 
+// DelCookie 删除 Cookie，可用于移除继承自 Client 的默认 Cookie
+func (r *Request) DelCookie(key string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	delete(r.Cookies, key)
+	return r
+}
+
 // SetContentType 设置请求体类型
 func (r *Request) SetContentType(contentType string) *Request {
 	r.clock.Lock()
@@ -371,10 +735,36 @@ func (r *Request) SetUserAgent(ua string) *Request {
 	return r
 }
 
+// SetProfile 使用浏览器特征，按浏览器的发送顺序设置 UA 与匹配的请求头，
+// 非有序模式下 fasthttp 会调整 User-Agent 等请求头的位置，因此会开启有序请求头模式
+func (r *Request) SetProfile(profile *BrowserProfile) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.applyProfile(profile)
+	return r
+}
+
+func (r *Request) applyProfile(profile *BrowserProfile) {
+	if !r.orderedHeaders {
+		r.orderedHeaders = true
+		enableOrderedHeader(&r.Headers)
+	}
+	// 先删除再添加，使已存在的请求头也按浏览器的顺序发送
+	for _, header := range profile.Headers {
+		delOrderedHeader(&r.Headers, header[0])
+		r.Headers.Add(header[0], header[1])
+	}
+	r.UserAgent = profile.UserAgent
+}
+
 // SetQueryParam 设置URL请求参数
 func (r *Request) SetQueryParam(key, value string) *Request {
 	r.clock.Lock()
 	defer r.clock.Unlock()
+	if r.rawQuery {
+		r.queryParams = setQueryParam(r.queryParams, queryParam{key: key, value: value})
+		return r
+	}
 	if r.QueryParam == nil {
 		r.QueryParam = _url.Values{}
 	}
@@ -382,6 +772,61 @@ func (r *Request) SetQueryParam(key, value string) *Request {
 	return r
 }
 
+// AddQueryParam 添加URL请求参数，不会覆盖已有的同名参数，可用于发送重复的参数
+func (r *Request) AddQueryParam(key, value string) *Request {
+	return r.addQueryParam(queryParam{key: key, value: value})
+}
+
+// AddRawQueryParam 添加不进行转义的URL请求参数，仅在原始查询模式下生效，否则等同于 `AddQueryParam`
+func (r *Request) AddRawQueryParam(key, value string) *Request {
+	return r.addQueryParam(queryParam{key: key, value: value, raw: true})
+}
+
+// AddQueryKey 添加只有参数名的URL请求参数，如 ?debug，仅在原始查询模式下生效，否则会发送为 debug=
+func (r *Request) AddQueryKey(key string) *Request {
+	return r.addQueryParam(queryParam{key: key, noValue: true})
+}
+
+func (r *Request) addQueryParam(param queryParam) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	if r.rawQuery {
+		r.queryParams = append(r.queryParams, param)
+		return r
+	}
+	if r.QueryParam == nil {
+		r.QueryParam = _url.Values{}
+	}
+	r.QueryParam.Add(param.key, param.value)
+	return r
+}
+
+// SetRawQuery 设置原始查询模式，开启后 URL 中的查询字符串原样发送（不解码、不排序、不重新转义），
+// 之后按添加顺序拼接通过 SetQueryParam、AddQueryParam 等方法设置的参数，可发送重复参数与只有参数名的参数，如 ?a&a=1。
+// 开启时已有的参数（包括继承自 Client 的默认参数）按参数名排序后保留
+func (r *Request) SetRawQuery(b bool) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	if b == r.rawQuery {
+		return r
+	}
+	r.rawQuery = b
+	if b {
+		r.queryParams = valuesToQueryParams(r.QueryParam)
+		r.QueryParam = _url.Values{}
+		return r
+	}
+
+	if r.QueryParam == nil {
+		r.QueryParam = _url.Values{}
+	}
+	for _, p := range r.queryParams {
+		r.QueryParam.Add(p.key, p.value)
+	}
+	r.queryParams = nil
+	return r
+}
+
 // SetQueryParams 设置URL请求参数
 func (r *Request) SetQueryParams(params map[string]string) *Request {
 	for k, v := range params {
@@ -390,6 +835,47 @@ func (r *Request) SetQueryParams(params map[string]string) *Request {
 	return r
 }
 
+// DelQueryParam 删除URL请求参数，可用于移除继承自 Client 的默认请求参数
+func (r *Request) DelQueryParam(key string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.QueryParam.Del(key)
+	r.queryParams = delQueryParam(r.queryParams, key)
+	return r
+}
+
+// SetPathParam 设置 URL 路径参数，替换 URL 中的 {key} 占位符，value 会进行转义
+func (r *Request) SetPathParam(key, value string) *Request {
+	return r.SetRawPathParam(key, _url.PathEscape(value))
+}
+
+// SetPathParams 设置 URL 路径参数，value 会进行转义
+func (r *Request) SetPathParams(params map[string]string) *Request {
+	for k, v := range params {
+		r.SetPathParam(k, v)
+	}
+	return r
+}
+
+// SetRawPathParam 设置 URL 路径参数，value 原样替换，适合已转义或包含多级路径的参数
+func (r *Request) SetRawPathParam(key, value string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	if r.pathParams == nil {
+		r.pathParams = make(map[string]string)
+	}
+	r.pathParams[key] = value
+	return r
+}
+
+// SetRawPathParams 设置 URL 路径参数，value 原样替换
+func (r *Request) SetRawPathParams(params map[string]string) *Request {
+	for k, v := range params {
+		r.SetRawPathParam(k, v)
+	}
+	return r
+}
+
 // SetFormData 设置 form-data 请求体参数
 func (r *Request) SetFormData(key, value string) *Request {
 	r.clock.Lock()
@@ -437,6 +923,63 @@ func (r *Request) SetBasicAuth(username, password string) *Request {
 	return r
 }
 
+// DelBasicAuth 删除 Basic 认证，可用于移除继承自 Client 的默认认证
+func (r *Request) DelBasicAuth() *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.BasicAuth = nil
+	return r
+}
+
+// SetBaseURL 设置基础 URL，覆盖继承自 Client 的基础 URL，传入空字符串时不使用基础 URL
+func (r *Request) SetBaseURL(baseURL string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.baseURL = strings.TrimRight(baseURL, "/")
+	return r
+}
+
+// DisableCache 跳过响应缓存，直接请求服务器且不更新缓存
+func (r *Request) DisableCache() *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.noCache = true
+	return r
+}
+
+func (r *Request) cacheDisabled() bool {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	return r.noCache
+}
+
+// SetConnectAddress 设置实际建立连接的地址（ip 或 ip:port），Host 请求头与 TLS SNI 仍使用 URL 中的域名，
+// 适用于虚拟主机探测、绕过 CDN 直连源站等场景，类似 curl 的 --connect-to。
+// 重定向时仅对同一域名生效，指定端口时还要求端口与原始请求一致
+func (r *Request) SetConnectAddress(addr string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.connectAddress = addr
+	return r
+}
+
+// SetSNI 设置 TLS 握手时使用的 SNI，默认使用 URL 中的域名，重定向时仅对同一域名生效
+func (r *Request) SetSNI(name string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.sni = name
+	return r
+}
+
+// SetUnixSocket 通过 Unix socket 建立连接，URL 中的主机仅用于 Host 请求头，重定向时仅对同一域名生效，
+// 也可以直接使用 http+unix:///var/run/docker.sock/v1.43/info 形式的地址，传入空字符串时取消
+func (r *Request) SetUnixSocket(path string) *Request {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	r.unixSocket = path
+	return r
+}
+
 // AllowRedirect 允许重定向
 func (r *Request) AllowRedirect() *Request {
 	r.clock.Lock()
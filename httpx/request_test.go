@@ -0,0 +1,37 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestPathParams(t *testing.T) {
+	var last *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	_, err := client.R().
+		SetPathParams(map[string]string{"id": "a b/c", "keyId": "7"}).
+		SetRawPathParam("rest", "x/y%20z").
+		SetQueryParam("q", "{id}").
+		Get(server.URL + "/users/{id}/keys/{keyId}/{rest}?page={id}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.EscapedPath() != "/users/a%20b%2Fc/keys/7/x/y%20z" {
+		t.Fatalf("unexpected path: %s", last.URL.EscapedPath())
+	}
+	if query := last.URL.Query(); query.Get("page") != "{id}" || query.Get("q") != "{id}" {
+		t.Fatalf("query should not be substituted: %s", last.URL.RawQuery)
+	}
+
+	_, err = client.R().SetPathParam("id", "1").Get(server.URL + "/users/{id}/keys/{keyId}")
+	if err == nil || !strings.Contains(err.Error(), "keyId") {
+		t.Fatalf("expected unresolved path param error, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/dns/dnsmessage"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Resolver 域名解析器，`net.Resolver` 已实现该接口
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewResolver 根据 DNS 服务器地址创建解析器，支持以下格式，多个服务器时按顺序依次尝试：
+//   - 8.8.8.8、8.8.8.8:53、udp://8.8.8.8:53：基于 UDP 的 DNS 查询，默认端口 53
+//   - tcp://8.8.8.8:53：基于 TCP 的 DNS 查询
+//   - https://1.1.1.1/dns-query：DNS over HTTPS（RFC 8484）
+func NewResolver(servers ...string) (Resolver, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no dns server specified")
+	}
+
+	var resolvers chainResolver
+	for _, server := range servers {
+		r, err := newServerResolver(server)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, r)
+	}
+
+	if len(resolvers) == 1 {
+		return resolvers[0], nil
+	}
+	return resolvers, nil
+}
+
+func newServerResolver(server string) (Resolver, error) {
+	network, address := "udp", server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		switch u.Scheme {
+		case "udp", "tcp":
+			network, address = u.Scheme, u.Host
+		case "https":
+			return &dohResolver{endpoint: u.String(), client: &fasthttp.Client{}}, nil
+		default:
+			return nil, fmt.Errorf("unsupported dns server scheme: %s", server)
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}, nil
+}
+
+// chainResolver 依次使用多个解析器进行解析，直到解析成功或域名确定不存在
+type chainResolver []Resolver
+
+func (c chainResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var err error
+	for _, r := range c {
+		var addrs []net.IPAddr
+		addrs, err = r.LookupIPAddr(ctx, host)
+		if err == nil {
+			return addrs, nil
+		}
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// dohResolver DNS over HTTPS 解析器
+type dohResolver struct {
+	endpoint string
+	client   *fasthttp.Client
+}
+
+func (d *dohResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	name, err := dnsmessage.NewName(dnsName(host))
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: host}
+	}
+
+	var addrs []net.IPAddr
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		ips, err := d.exchange(ctx, name, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		addrs = append(addrs, ips...)
+	}
+
+	if len(addrs) == 0 {
+		if lastErr == nil {
+			lastErr = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, lastErr
+	}
+	return addrs, nil
+}
+
+// exchange 发送一次 DNS 查询
+func (d *dohResolver) exchange(ctx context.Context, name dnsmessage.Name, qtype dnsmessage.Type) ([]net.IPAddr, error) {
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: name, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseResponse(resp)
+		fasthttp.ReleaseRequest(req)
+	}()
+
+	req.SetRequestURI(d.endpoint)
+	req.Header.SetMethod(MethodPost)
+	req.Header.SetContentType("application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	req.SetBody(packed)
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(fasthttp.DefaultDialTimeout)
+	}
+	if err = d.client.DoDeadline(req, resp, deadline); err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name.String(), Server: d.endpoint}
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, &net.DNSError{
+			Err:    fmt.Sprintf("unexpected status code %d", resp.StatusCode()),
+			Name:   name.String(),
+			Server: d.endpoint,
+		}
+	}
+
+	var answer dnsmessage.Message
+	if err = answer.Unpack(resp.Body()); err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name.String(), Server: d.endpoint}
+	}
+	if answer.RCode == dnsmessage.RCodeNameError {
+		return nil, &net.DNSError{Err: "no such host", Name: name.String(), Server: d.endpoint, IsNotFound: true}
+	}
+	if answer.RCode != dnsmessage.RCodeSuccess {
+		return nil, &net.DNSError{Err: answer.RCode.String(), Name: name.String(), Server: d.endpoint}
+	}
+
+	var addrs []net.IPAddr
+	for _, rr := range answer.Answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(body.A[:])})
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(body.AAAA[:])})
+		}
+	}
+	return addrs, nil
+}
+
+// dnsName 转换为以 . 结尾的完整域名
+func dnsName(host string) string {
+	if len(host) > 0 && host[len(host)-1] == '.' {
+		return host
+	}
+	return host + "."
+}
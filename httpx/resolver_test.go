@@ -0,0 +1,150 @@
+package httpx
+
+import (
+	"context"
+	"golang.org/x/net/dns/dnsmessage"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dnsAnswer 构造 DNS 响应，A 记录统一解析到 127.0.0.1
+func dnsAnswer(t *testing.T, packet []byte) []byte {
+	var query dnsmessage.Message
+	if err := query.Unpack(packet); err != nil {
+		t.Error(err)
+		return nil
+	}
+
+	answer := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+		Questions: query.Questions,
+	}
+	q := query.Questions[0]
+	if q.Type == dnsmessage.TypeA {
+		answer.Answers = append(answer.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+		})
+	}
+
+	packed, err := answer.Pack()
+	if err != nil {
+		t.Error(err)
+	}
+	return packed
+}
+
+func newTestServer() (*httptest.Server, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	u, _ := url.Parse(server.URL)
+	return server, u.Port()
+}
+
+func TestHostOverride(t *testing.T) {
+	server, port := newTestServer()
+	defer server.Close()
+
+	client := NewClient().SetHostOverride("example.invalid:"+port, "127.0.0.1")
+	resp, err := client.R().Get("http://example.invalid:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyString() != "example.invalid:"+port {
+		t.Fatalf("unexpected host: %s", resp.BodyString())
+	}
+}
+
+type countResolver struct {
+	count atomic.Int32
+}
+
+func (r *countResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.count.Add(1)
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestDNSNegativeCache(t *testing.T) {
+	resolver := &countResolver{}
+	dialer := NewDialer().SetResolver(resolver).SetDNSCacheDuration(time.Hour, time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := dialer.Dial("example.invalid:80"); err == nil {
+			t.Fatal("expected lookup error")
+		}
+	}
+	if n := resolver.count.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup, got %d", n)
+	}
+}
+
+func TestUDPResolver(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(dnsAnswer(t, buf[:n]), addr)
+		}
+	}()
+
+	server, port := newTestServer()
+	defer server.Close()
+
+	resolver, err := NewResolver("udp://" + pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := resolver.LookupIPAddr(context.Background(), "example.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("unexpected addrs: %v", addrs)
+	}
+
+	resp, err := NewClient().SetResolver("udp://" + pc.LocalAddr().String()).R().
+		Get("http://example.invalid:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BodyString() != "example.invalid:"+port || resp.Timing().RemoteAddr != "127.0.0.1:"+port {
+		t.Fatalf("unexpected response: %s, %v", resp.BodyString(), resp.Timing())
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	doh := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		packet, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(dnsAnswer(t, packet))
+	}))
+	defer doh.Close()
+
+	resolver, err := NewResolver(doh.URL + "/dns-query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver.(*dohResolver).client.TLSConfig = doh.Client().Transport.(*http.Transport).TLSClientConfig
+
+	addrs, err := resolver.LookupIPAddr(context.Background(), "example.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("unexpected addrs: %v", addrs)
+	}
+}
@@ -1,6 +1,7 @@
 package httpx
 
 import (
+	"crypto/tls"
 	"github.com/valyala/fasthttp"
 )
 
@@ -14,6 +15,7 @@ type Response struct {
 	OriginalRequest  fasthttp.Request
 	OriginalResponse fasthttp.Response
 
+	url             string      // 响应对应的请求地址，重定向时为每一跳的地址
 	header          Header      // 响应头
 	headerBytes     []byte      // 响应头字节
 	body            []byte      // 响应体
@@ -21,12 +23,24 @@ type Response struct {
 	respSize        int         // 响应长度（响应头+响应体）
 	location        string      // 30X跳转后的地址
 	responseHistory []*Response // 允许重定向跳转时，记录每次请求的响应，包括最后一次请求也会记录
+	timing          *Timing     // 各阶段耗时
+	cacheStatus     CacheStatus // 缓存状态
+}
+
+// URL 获取响应对应的请求地址，跟随重定向时最终响应的地址即为最后一跳的地址
+func (r *Response) URL() string {
+	return r.url
 }
 
 func (r *Response) Status() int {
 	return r.OriginalResponse.StatusCode()
 }
 
+// Proto 获取响应实际使用的协议，如 HTTP/1.1、HTTP/2.0
+func (r *Response) Proto() string {
+	return string(r.OriginalResponse.Header.Protocol())
+}
+
 func (r *Response) Header() Header {
 	return r.header
 }
@@ -63,6 +77,33 @@ func (r *Response) ResponseHistory() []*Response {
 	return r.responseHistory
 }
 
+// Timing 获取本次请求各阶段耗时，重定向时每一跳的响应都有各自的耗时统计
+func (r *Response) Timing() *Timing {
+	return r.timing
+}
+
+// TLS 获取响应所在连接的 TLS 信息，非 TLS 连接或响应来自缓存时返回 nil
+func (r *Response) TLS() *tls.ConnectionState {
+	if r.timing == nil {
+		return nil
+	}
+	return r.timing.tlsState
+}
+
+// WireDump 获取本次请求在连接上实际发送与接收的明文字节，按时间顺序排列，
+// 需要通过 `Client.SetWireDump` 开启，未开启、响应来自缓存、使用 HTTP/2 或自定义的传输层时返回 nil
+func (r *Response) WireDump() []byte {
+	if r.timing == nil {
+		return nil
+	}
+	return r.timing.wire
+}
+
+// CacheStatus 获取响应的缓存状态，用于判断响应是否来自缓存
+func (r *Response) CacheStatus() CacheStatus {
+	return r.cacheStatus
+}
+
 func (r *Response) String() string {
 	return r.OriginalResponse.String()
 }
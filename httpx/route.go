@@ -0,0 +1,183 @@
+package httpx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net"
+	_url "net/url"
+	"os"
+	"strings"
+)
+
+// route 请求的连接路由，用于将实际连接地址、TLS SNI 与 URL 解耦
+//
+// fasthttp 根据 URI 中的 host 选择连接池并建立连接，因此这里将 `target@sni@connect` 编码到 URI 的 host 中，
+// 再由 `Client.dialHost` 解码后建立连接，target 为原始请求的 host:port，Host 请求头仍然使用 URL 中的 host
+type route struct {
+	hostname string // 原始请求的域名
+	port     string // 原始请求的端口
+	connect  string // 实际连接的地址，ip 或 ip:port
+	sni      string // TLS 握手时使用的 SNI
+	socket   string // Unix socket 路径，不为空时忽略 connect
+}
+
+// apply 对请求应用路由，仅对与原始请求域名相同的请求生效（如重定向到同一域名），
+// 当连接地址指定了端口时，还要求端口与原始请求一致
+func (rt *route) apply(req *fasthttp.Request) {
+	if rt == nil {
+		return
+	}
+
+	uri := req.URI()
+	host := string(uri.Host())
+	isTLS := string(uri.Scheme()) == "https"
+	hostname, port := splitHostPort(host, isTLS)
+	// 路由不生效时（如重定向到其他主机），恢复上一跳设置的 Host
+	reset := func() {
+		if req.UseHostHeader {
+			req.UseHostHeader = false
+			req.Header.SetHost(host)
+		}
+	}
+	if !strings.EqualFold(hostname, rt.hostname) {
+		reset()
+		return
+	}
+
+	target := net.JoinHostPort(hostname, port)
+	connect := target
+	if rt.socket != "" {
+		connect = unixRouteAddr(rt.socket)
+	} else if rt.connect != "" {
+		if _, _, err := net.SplitHostPort(rt.connect); err != nil {
+			connect = net.JoinHostPort(strings.Trim(rt.connect, "[]"), port)
+		} else if port == rt.port {
+			connect = rt.connect
+		}
+	}
+
+	sni := hostname
+	if rt.sni != "" && isTLS {
+		sni = rt.sni
+	}
+
+	if connect == target && sni == hostname {
+		reset()
+		return
+	}
+
+	req.Header.SetHost(host)
+	req.UseHostHeader = true
+	uri.SetHost(target + "@" + sni + "@" + connect)
+}
+
+// parseRouteAddr 解析经过路由编码的连接地址，返回原始请求的 host:port、SNI 与实际连接的地址，
+// 未经过路由编码时 target、connect 均为 addr
+func parseRouteAddr(addr string) (target, sni, connect string, ok bool) {
+	target, rest, ok := strings.Cut(addr, "@")
+	if ok {
+		sni, connect, ok = strings.Cut(rest, "@")
+	}
+	if !ok {
+		return addr, "", addr, false
+	}
+	return target, sni, connect, true
+}
+
+// unixAddrPrefix Unix socket 连接地址的前缀
+const unixAddrPrefix = "unix:"
+
+// unixRouteAddr 将 Unix socket 路径编码为连接地址，fasthttp 会将 URI 中的 host 转为小写，因此路径使用十六进制编码
+func unixRouteAddr(socket string) string {
+	return unixAddrPrefix + hex.EncodeToString([]byte(socket))
+}
+
+// parseUnixRouteAddr 解析 Unix socket 连接地址，返回 socket 路径
+func parseUnixRouteAddr(addr string) (string, bool) {
+	encoded, ok := strings.CutPrefix(addr, unixAddrPrefix)
+	if !ok {
+		return "", false
+	}
+	socket, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	return string(socket), true
+}
+
+// unixSocketHost Unix socket 请求默认使用的 Host
+const unixSocketHost = "localhost"
+
+// parseUnixURL 将 http+unix、https+unix 地址转换为普通地址，并返回 socket 路径，其他地址原样返回。
+// socket 路径推荐经过 URL 编码放在主机中，如 http+unix://%2Fvar%2Frun%2Fdocker.sock/v1.43/info，
+// 也可以直接放在路径中，如 http+unix:///var/run/docker.sock/v1.43/info，
+// 此时使用路径中第一个存在的 socket 文件，每次请求时重新检查，均不存在时返回错误
+func parseUnixURL(url string) (string, string, error) {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok {
+		return url, "", nil
+	}
+	scheme, ok = strings.CutSuffix(strings.ToLower(scheme), "+unix")
+	if !ok {
+		return url, "", nil
+	}
+
+	var socket string
+	if strings.HasPrefix(rest, "/") {
+		end := strings.IndexAny(rest, "?#")
+		if end < 0 {
+			end = len(rest)
+		}
+		if socket = findUnixSocket(rest[:end]); socket == "" {
+			return "", "", fmt.Errorf("no unix socket found in %s", url)
+		}
+		rest = rest[len(socket):]
+	} else {
+		end := strings.IndexAny(rest, "/?#")
+		if end < 0 {
+			end = len(rest)
+		}
+		var err error
+		if socket, err = _url.PathUnescape(rest[:end]); err != nil || socket == "" {
+			return "", "", fmt.Errorf("invalid unix socket in %s", url)
+		}
+		rest = rest[end:]
+	}
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	return scheme + "://" + unixSocketHost + rest, socket, nil
+}
+
+// findUnixSocket 从路径中找出 socket 文件的路径，与内核解析路径的方式相同，按层级依次检查，
+// 遇到不存在或不是目录的层级时停止，未找到时返回空字符串
+func findUnixSocket(path string) string {
+	for i := 1; i <= len(path); i++ {
+		if i < len(path) && path[i] != '/' {
+			continue
+		}
+		info, err := os.Stat(path[:i])
+		if err != nil {
+			return ""
+		}
+		if info.Mode()&os.ModeSocket != 0 {
+			return path[:i]
+		}
+		if !info.IsDir() {
+			return ""
+		}
+	}
+	return ""
+}
+
+// splitHostPort 拆分 host 与端口，没有端口时按协议补全默认端口
+func splitHostPort(host string, isTLS bool) (string, string) {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return h, p
+	}
+	if isTLS {
+		return strings.Trim(host, "[]"), "443"
+	}
+	return strings.Trim(host, "[]"), "80"
+}
@@ -0,0 +1,175 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectAddress(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte(r.Host + " " + r.TLS.ServerName))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	target := "https://a.example.com:" + u.Port()
+	client := NewClient()
+
+	resp, err := client.R().SetConnectAddress(u.Host).Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a.example.com:" + u.Port() + " a.example.com"; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+
+	resp, err = client.R().SetConnectAddress("127.0.0.1").SetSNI("b.example.com").Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a.example.com:" + u.Port() + " b.example.com"; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+
+	resp, err = client.R().SetConnectAddress(u.Host).AllowRedirect().Get(target + "/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a.example.com:" + u.Port() + " a.example.com"; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+}
+
+func TestConnectAddressRedirect(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer other.Close()
+	o, _ := url.Parse(other.URL)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	client := NewClient().SetHostOverride("a.example.com:"+o.Port(), "127.0.0.1")
+	target := "http://a.example.com:" + u.Port() + "/?to="
+	for _, location := range []string{
+		// 重定向到其他主机
+		other.URL + "/final",
+		// 重定向到同一主机的其他端口，连接地址指定了端口，路由不生效
+		"http://a.example.com:" + o.Port() + "/final",
+	} {
+		resp, err := client.R().SetConnectAddress(u.Host).AllowRedirect().Get(target + url.QueryEscape(location))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, _ := url.Parse(location)
+		if resp.BodyString() != l.Host {
+			t.Fatalf("redirect to %s: expected host %q, got %q", location, l.Host, resp.BodyString())
+		}
+	}
+}
+
+// newUnixServer 启动监听 Unix socket 的 HTTP 服务
+func newUnixServer(t *testing.T, handler http.Handler) string {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix socket not supported: %v", err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return socket
+}
+
+func TestUnixSocket(t *testing.T) {
+	socket := newUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.43/redirect" {
+			http.Redirect(w, r, "/v1.43/info", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte(r.Method + " " + r.Host + " " + r.URL.RequestURI()))
+	}))
+	client := NewClient()
+
+	tests := []struct {
+		request  *Request
+		url      string
+		expected string
+	}{
+		{client.R(), "http+unix://" + socket + "/v1.43/containers/json?all=1", "GET localhost /v1.43/containers/json?all=1"},
+		{client.R(), "http+unix://" + url.PathEscape(socket) + "/v1.43/info", "GET localhost /v1.43/info"},
+		{client.R().SetUnixSocket(socket), "http://docker/v1.43/version", "GET docker /v1.43/version"},
+		{client.R().AllowRedirect(), "http+unix://" + socket + "/v1.43/redirect", "GET localhost /v1.43/info"},
+	}
+	for _, test := range tests {
+		resp, err := test.request.Get(test.url)
+		if err != nil {
+			t.Fatalf("%s: %v", test.url, err)
+		}
+		if resp.BodyString() != test.expected {
+			t.Fatalf("%s: expected %q, got %q", test.url, test.expected, resp.BodyString())
+		}
+	}
+
+	// 基于 net/http 的传输层同样支持
+	resp, err := NewClient().SetTransport(NewHttpTransport()).R().Post("http+unix://" + socket + "/v1.43/build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "POST localhost /v1.43/build"; resp.BodyString() != expected {
+		t.Fatalf("expected %q, got %q", expected, resp.BodyString())
+	}
+}
+
+func TestParseUnixURL(t *testing.T) {
+	socket := newUnixServer(t, http.NotFoundHandler())
+	tests := []struct {
+		url, expected, socket string
+	}{
+		{"http+unix://" + socket + "/v1.43/info", "http://localhost/v1.43/info", socket},
+		{"https+unix://" + socket, "https://localhost/", socket},
+		{"http+unix://%2Ftmp%2Fa%2Fb/path?q=1", "http://localhost/path?q=1", "/tmp/a/b"},
+		{"http+unix://%2Ftmp%2Fsock?q=1", "http://localhost/?q=1", "/tmp/sock"},
+		{"http://example.com/a", "http://example.com/a", ""},
+	}
+	for _, test := range tests {
+		actual, socket, err := parseUnixURL(test.url)
+		if err != nil || actual != test.expected || socket != test.socket {
+			t.Errorf("parseUnixURL(%s) = %s, %s, %v", test.url, actual, socket, err)
+		}
+	}
+	if _, _, err := parseUnixURL("http+unix:///no/socket/here"); err == nil {
+		t.Error("expected error for missing socket")
+	}
+	// 不存在的路径不会被猜测为 socket
+	if _, _, err := parseUnixURL("http+unix:///var/run/missing.sock/info"); err == nil {
+		t.Error("expected error for missing socket file")
+	}
+	// socket 文件被删除后不再使用
+	_ = os.Remove(socket)
+	if _, _, err := parseUnixURL("http+unix://" + socket + "/v1.43/info"); err == nil {
+		t.Error("expected error for removed socket")
+	}
+
+	// 大写字母在编码后不受 fasthttp 小写转换的影响
+	if socket, ok := parseUnixRouteAddr(unixRouteAddr("/Tmp/A.sock")); !ok || socket != "/Tmp/A.sock" {
+		t.Errorf("unexpected socket %q", socket)
+	}
+}
@@ -0,0 +1,286 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event Server-Sent Events 事件
+type Event struct {
+	ID    string        // 事件 ID，未设置时继承之前收到的 ID
+	Event string        // 事件类型，默认为 message
+	Data  string        // 事件数据，多行 data 使用 \n 连接
+	Retry time.Duration // 事件中 retry 字段指定的重连时间，未设置时为 0
+}
+
+// StreamOptions SSE 连接配置
+type StreamOptions struct {
+	Method      string        // 请求方法，默认为 GET，需要发送请求体时可以使用 POST
+	LastEventID string        // 首次连接时发送的 Last-Event-ID
+	RetryDelay  time.Duration // 重连等待时间，默认为 3 秒，服务端通过 retry 字段指定时以服务端为准
+	MaxRetries  int           // 连续重连失败的最大次数，为 0 时不限制
+	MaxLineSize int           // 单行的最大字节数，默认为 1MB，超过时返回错误并结束
+}
+
+// errStreamClosed 服务端关闭了事件流
+var errStreamClosed = errors.New("event stream closed")
+
+// Stream 建立 SSE 连接并返回事件迭代器，连接使用请求的请求头、Cookie、认证信息以及 Client 的 Dial、代理、TLS 等配置。
+// 连接断开时使用 Last-Event-ID 自动重连，响应状态码不是 200 或 Content-Type 不是 text/event-stream 时返回错误并结束，
+// 服务端返回 204 或 ctx 取消时结束迭代
+func (r *Request) Stream(ctx context.Context, url string, opts *StreamOptions) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		if opts == nil {
+			opts = &StreamOptions{}
+		}
+		method := opts.Method
+		if method == "" {
+			method = MethodGet
+		}
+		retry := opts.RetryDelay
+		if retry <= 0 {
+			retry = 3 * time.Second
+		}
+
+		r.clock.Lock()
+		prepared := r.clone()
+		r.clock.Unlock()
+		defer r.syncPrepared(prepared)
+
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		if err := prepared.preCheck(url, method, req); err != nil {
+			yield(nil, fmt.Errorf("preCheck err: %v", err))
+			return
+		}
+		prepared.route().apply(req)
+		host := string(req.URI().Host())
+		target, sni, connect, routed := parseRouteAddr(host)
+		if routed {
+			host = string(req.Header.Host())
+		}
+
+		cli := prepared.client
+		transport := cli.streamingTransport().transport(cli, target, sni, connect, routed)
+		cli.clock.Lock()
+		noDefaultUserAgent := cli.NoDefaultUserAgentHeader
+		cli.clock.Unlock()
+
+		maxLineSize := opts.MaxLineSize
+		if maxLineSize <= 0 {
+			maxLineSize = 1024 * 1024
+		}
+		s := &eventStream{lastEventID: opts.LastEventID, retry: retry, maxLineSize: maxLineSize}
+		failures := 0
+		for {
+			// 记录本次连接的明文，连接结束后写入 SetWireDumpWriter 设置的位置
+			var wire *wireBuffer
+			traceCtx := httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					if ta, ok := info.Conn.RemoteAddr().(*traceAddr); ok {
+						wire = ta.exchange.wire
+					}
+				},
+			})
+			flush := func() {
+				if b := wire.bytes(); b != nil {
+					cli.writeWireDump(b)
+				}
+			}
+
+			httpReq, err := toHttpRequest(traceCtx, req, host, noDefaultUserAgent)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if httpReq.Header.Get("Accept") == "" {
+				httpReq.Header.Set("Accept", "text/event-stream")
+			}
+			httpReq.Header.Set("Cache-Control", "no-cache")
+			if s.lastEventID != "" {
+				httpReq.Header.Set("Last-Event-ID", s.lastEventID)
+			}
+
+			httpResp, err := transport.RoundTrip(httpReq)
+			if err == nil {
+				if httpResp.StatusCode == http.StatusNoContent {
+					httpResp.Body.Close()
+					flush()
+					return
+				}
+				if err = checkEventStream(httpResp); err != nil {
+					httpResp.Body.Close()
+					flush()
+					yield(nil, err)
+					return
+				}
+				failures = 0
+				var stop bool
+				stop, err = s.read(httpResp.Body, yield)
+				httpResp.Body.Close()
+				flush()
+				if stop {
+					return
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			if opts.MaxRetries > 0 && failures > opts.MaxRetries {
+				yield(nil, fmt.Errorf("event stream reconnect failed after %d retries: %w", opts.MaxRetries, err))
+				return
+			}
+
+			timer := time.NewTimer(s.retry)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if metrics := cli.Metrics(); metrics != nil {
+				metrics.AddRetry(host)
+			}
+		}
+	}
+}
+
+// checkEventStream 检查响应是否为事件流
+func checkEventStream(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("event stream response status %d: %s", resp.StatusCode, body)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		return fmt.Errorf("unexpected event stream content type: %s", resp.Header.Get("Content-Type"))
+	}
+	return nil
+}
+
+// streamingTransport 获取流式请求使用的传输层
+func (cli *Client) streamingTransport() *HttpTransport {
+	cli.clock.Lock()
+	defer cli.clock.Unlock()
+	if t, ok := cli.transport.(*HttpTransport); ok {
+		return t
+	}
+	if cli.streamTransport == nil {
+		cli.streamTransport = &HttpTransport{}
+	}
+	return cli.streamTransport
+}
+
+// eventStream 事件流的解析状态，重连后保留
+type eventStream struct {
+	lastEventID string
+	retry       time.Duration
+	maxLineSize int
+}
+
+// scanEventLines 按 CRLF、LF 或单独的 CR 切分行，末尾不完整的行不返回
+func scanEventLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	i := bytes.IndexAny(data, "\r\n")
+	if i < 0 {
+		return 0, nil, nil
+	}
+	if data[i] == '\n' {
+		return i + 1, data[:i], nil
+	}
+	if i+1 < len(data) {
+		if data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return i + 1, data[:i], nil
+	}
+	// CR 位于末尾时需要读取下一个字节判断是否为 CRLF
+	return 0, nil, nil
+}
+
+// read 按照 HTML 标准解析事件流，yield 返回 false 或单行超过长度限制时 stop 为 true，连接断开时返回读取错误
+func (s *eventStream) read(body io.Reader, yield func(*Event, error) bool) (stop bool, err error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, min(4096, s.maxLineSize)), s.maxLineSize)
+	scanner.Split(scanEventLines)
+	var (
+		data      strings.Builder
+		hasData   bool
+		eventType string
+		retry     time.Duration
+		first     = true
+	)
+	for {
+		if !scanner.Scan() {
+			// 不完整的事件直接丢弃
+			err := scanner.Err()
+			if errors.Is(err, bufio.ErrTooLong) {
+				yield(nil, fmt.Errorf("event stream line exceeds %d bytes", s.maxLineSize))
+				return true, nil
+			}
+			if err == nil {
+				err = errStreamClosed
+			}
+			return false, err
+		}
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if hasData {
+				event := &Event{ID: s.lastEventID, Event: eventType, Data: strings.TrimSuffix(data.String(), "\n"), Retry: retry}
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				if !yield(event, nil) {
+					return true, nil
+				}
+			}
+			data.Reset()
+			hasData, eventType, retry = false, "", 0
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				retry = time.Duration(ms) * time.Millisecond
+				s.retry = retry
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestStream(t *testing.T) {
+	var mu sync.Mutex
+	var lastEventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		connections := len(lastEventIDs)
+		mu.Unlock()
+
+		if r.Header.Get("X-Token") != "abc" || r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if connections > 2 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		if connections == 1 {
+			fmt.Fprint(w, "\ufeff: comment\nretry: 10\n\n")
+			fmt.Fprint(w, "id: 1\nevent: update\ndata: line1\ndata:line2\n\n")
+			fmt.Fprint(w, "data: inherit id\r\n\r\n")
+			fmt.Fprint(w, "id: 2\ndata: incomplete")
+			return
+		}
+		fmt.Fprint(w, "id: 3\ndata: after reconnect\n\n")
+	}))
+	defer server.Close()
+
+	var events []*Event
+	for event, err := range NewClient().R().SetHeader("X-Token", "abc").Stream(context.Background(), server.URL+"/", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+
+	expected := []Event{
+		{ID: "1", Event: "update", Data: "line1\nline2"},
+		{ID: "1", Event: "message", Data: "inherit id"},
+		{ID: "3", Event: "message", Data: "after reconnect"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i, event := range events {
+		if *event != expected[i] {
+			t.Fatalf("unexpected event %d: %+v", i, event)
+		}
+	}
+	// 不完整的事件被丢弃，但其中的 id 在重连时发送
+	if len(lastEventIDs) != 3 || lastEventIDs[0] != "" || lastEventIDs[1] != "2" || lastEventIDs[2] != "3" {
+		t.Fatalf("unexpected Last-Event-ID: %q", lastEventIDs)
+	}
+
+	// 响应不是事件流时返回错误
+	for _, err := range NewClient().R().Stream(context.Background(), server.URL+"/", nil) {
+		if err == nil {
+			t.Fatal("expected error for non event stream response")
+		}
+	}
+}
+
+func TestRequestStreamCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", i); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, err := range NewClient().R().Stream(ctx, server.URL+"/", nil) {
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if count++; count == 3 {
+				cancel()
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("stream not stopped after context canceled")
+	}
+	if count < 3 {
+		t.Fatalf("expected at least 3 events, got %d", count)
+	}
+}
+
+func TestEventStreamRead(t *testing.T) {
+	// 支持 CR、LF、CRLF 三种换行
+	s := &eventStream{maxLineSize: 64}
+	var events []*Event
+	_, err := s.read(strings.NewReader("id: 1\rdata: a\r\rdata: b\r\ndata: c\n\ndata: d\r"), func(event *Event, err error) bool {
+		events = append(events, event)
+		return err == nil
+	})
+	if err != errStreamClosed || len(events) != 2 || events[0].Data != "a" || events[1].Data != "b\nc" || s.lastEventID != "1" {
+		t.Fatalf("unexpected events: %+v, %v", events, err)
+	}
+
+	// 单行超过长度限制时返回错误并结束
+	var streamErr error
+	stop, err := s.read(strings.NewReader("data: "+strings.Repeat("x", 128)), func(event *Event, err error) bool {
+		streamErr = err
+		return false
+	})
+	if !stop || err != nil || streamErr == nil {
+		t.Fatalf("expected line too long error, got %v %v", stop, streamErr)
+	}
+}
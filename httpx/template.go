@@ -0,0 +1,60 @@
+package httpx
+
+// RequestTemplate 只读的请求模板，创建后不可修改，可以被多个 goroutine 并发执行，
+// 每次执行都基于模板创建独立的请求，执行结果互不影响
+type RequestTemplate struct {
+	req *Request
+}
+
+// Template 使用请求当前的配置创建请求模板，之后对请求的修改不会影响模板
+func (r *Request) Template() *RequestTemplate {
+	r.clock.Lock()
+	defer r.clock.Unlock()
+	return &RequestTemplate{req: r.clone()}
+}
+
+// R 基于模板创建新的请求，可以在此基础上继续修改配置
+func (t *RequestTemplate) R() *Request {
+	return t.req.clone()
+}
+
+// Do 基于模板创建新的请求并执行，method 为空时使用模板请求的方法
+func (t *RequestTemplate) Do(url, method string) (*Response, error) {
+	return t.R().do(url, method)
+}
+
+func (t *RequestTemplate) Get(url string) (*Response, error) {
+	return t.Do(url, MethodGet)
+}
+
+func (t *RequestTemplate) Head(url string) (*Response, error) {
+	return t.Do(url, MethodHead)
+}
+
+func (t *RequestTemplate) Post(url string) (*Response, error) {
+	return t.Do(url, MethodPost)
+}
+
+func (t *RequestTemplate) Put(url string) (*Response, error) {
+	return t.Do(url, MethodPut)
+}
+
+func (t *RequestTemplate) Patch(url string) (*Response, error) {
+	return t.Do(url, MethodPatch)
+}
+
+func (t *RequestTemplate) Delete(url string) (*Response, error) {
+	return t.Do(url, MethodDelete)
+}
+
+func (t *RequestTemplate) Connect(url string) (*Response, error) {
+	return t.Do(url, MethodConnect)
+}
+
+func (t *RequestTemplate) Options(url string) (*Response, error) {
+	return t.Do(url, MethodOptions)
+}
+
+func (t *RequestTemplate) Trace(url string) (*Response, error) {
+	return t.Do(url, MethodTrace)
+}
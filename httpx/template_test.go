@@ -0,0 +1,134 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRequestRepeatedDo(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	req := NewClient().R().SetQueryParam("foo", "bar")
+	for i := 0; i < 3; i++ {
+		if _, err := req.Get(server.URL + "/?a=1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, query := range queries {
+		if query != "a=1&foo=bar" {
+			t.Fatalf("query params duplicated: %v", queries)
+		}
+	}
+	if req.Url() != server.URL+"/?a=1&foo=bar" || req.Method != MethodGet || len(req.QueryParam) != 1 {
+		t.Fatalf("unexpected request state: %s %s %v", req.Method, req.Url(), req.QueryParam)
+	}
+
+	// 与 BenchmarkRequest_Get 相同，并发使用同一个请求
+	queries = nil
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := req.Get(server.URL + "/?a=1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, query := range queries {
+		if query != "a=1&foo=bar" {
+			t.Fatalf("query params duplicated: %v", queries)
+		}
+	}
+}
+
+func TestRequestClone(t *testing.T) {
+	requests := make(chan *http.Request, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer server.Close()
+
+	req := NewClient().R().
+		SetHeader("X-A", "1").
+		SetCookie("c", "1").
+		SetQueryParam("q", "1").
+		SetBasicAuth("user", "pass")
+	clone := req.Clone().
+		SetHeader("X-A", "2").
+		SetCookie("c", "2").
+		SetQueryParam("q", "2").
+		SetBasicAuth("other", "pass")
+
+	if _, err := req.Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	last := <-requests
+	auth := last.Header.Get("Authorization")
+	if last.Header.Get("X-A") != "1" || last.URL.Query().Get("q") != "1" {
+		t.Fatalf("clone modified original request: %v %s", last.Header, last.URL)
+	}
+
+	if _, err := clone.Get(server.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	last = <-requests
+	if cookie, err := last.Cookie("c"); err != nil || cookie.Value != "2" || last.Header.Get("X-A") != "2" ||
+		last.URL.Query().Get("q") != "2" || last.Header.Get("Authorization") == auth {
+		t.Fatalf("unexpected clone request: %v %s", last.Header, last.URL)
+	}
+}
+
+func TestRequestTemplate(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("X-Id")]++
+	}))
+	defer server.Close()
+
+	req := NewClient().R().SetHeader("X-Id", "tpl").SetQueryParam("foo", "bar")
+	tpl := req.Template()
+	// 创建模板后对请求的修改不影响模板
+	req.SetHeader("X-Id", "changed")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = tpl.Get(server.URL + "/path?a=1")
+			} else {
+				_, err = tpl.R().SetHeader("X-Id", "custom").Post(server.URL + "/path?a=1")
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, err := tpl.Trace(server.URL + "/trace"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if seen["GET /path?a=1&foo=bar tpl"] != 10 || seen["POST /path?a=1&foo=bar custom"] != 10 ||
+		seen["TRACE /trace?foo=bar tpl"] != 1 || len(seen) != 3 {
+		t.Fatalf("unexpected requests: %v", seen)
+	}
+}
@@ -0,0 +1,305 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timing 单次请求（重定向时为单跳）各阶段耗时
+type Timing struct {
+	DNSLookup        time.Duration // DNS 解析耗时，仅默认 Dialer 建立新连接时统计
+	TCPConnect       time.Duration // TCP 建连耗时，使用代理时为连接代理并建立隧道的耗时
+	TLSHandshake     time.Duration // TLS 握手耗时
+	ServerProcessing time.Duration // 请求发送完成到收到首字节的耗时
+	TimeToFirstByte  time.Duration // 请求开始到收到首字节的耗时
+	BodyRead         time.Duration // 收到首字节到响应读取完成的耗时
+	Total            time.Duration // 请求总耗时
+	ConnReused       bool          // 是否复用了已有连接
+	ConnIdleTime     time.Duration // 复用连接时，连接在连接池中的空闲时间
+	RemoteAddr       string        // 连接的远端地址
+	LocalAddr        string        // 连接的本地地址
+
+	tlsState *tls.ConnectionState // TLS 连接信息，通过 `Response.TLS` 获取
+	wire     []byte               // 连接上实际发送与接收的明文，通过 `Response.WireDump` 获取
+}
+
+func (t *Timing) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s server=%s ttfb=%s body=%s total=%s reused=%t",
+		t.DNSLookup, t.TCPConnect, t.TLSHandshake, t.ServerProcessing,
+		t.TimeToFirstByte, t.BodyRead, t.Total, t.ConnReused)
+}
+
+// connTiming 建立连接时各阶段耗时
+type connTiming struct {
+	dnsLookup    time.Duration
+	tcpConnect   time.Duration
+	tlsHandshake time.Duration
+}
+
+// connExchange 连接上的一次请求/响应交互
+type connExchange struct {
+	conn      *traceConn
+	seq       int // 该连接上的第几次交互，从 0 开始
+	idle      time.Duration
+	writeDone time.Time // 最后一次写入完成的时间
+	firstByte time.Time // 首个响应字节到达的时间
+	lastRead  time.Time
+	wire      *wireBuffer // 按时间顺序记录的明文，未开启时为 nil
+	header    headerOrder // 响应头的接收顺序
+}
+
+// maxWireSize 单次交互记录明文的最大字节数，超出的部分不再记录
+const maxWireSize = 1 << 20
+
+// wireBuffer 记录一次交互的明文，读写可能位于不同的 goroutine 中
+type wireBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (w *wireBuffer) write(b []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n := maxWireSize - w.buf.Len(); n < len(b) {
+		b = b[:max(n, 0)]
+		w.truncated = true
+	}
+	w.buf.Write(b)
+}
+
+// bytes 获取记录的明文，超出上限时末尾添加截断标记
+func (w *wireBuffer) bytes() []byte {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	wire := bytes.Clone(w.buf.Bytes())
+	if w.truncated {
+		wire = append(wire, "...(truncated)"...)
+	}
+	return wire
+}
+
+// maxHeaderOrder 记录响应头接收顺序的最大数量
+const maxHeaderOrder = 256
+
+// headerOrder 从 HTTP/1.x 连接读取的数据中记录响应头名称的接收顺序，读到（非 1xx）响应头结束后不再处理，
+// 用于 `net/http` 的响应转换，`http.Header` 本身不保留顺序
+type headerOrder struct {
+	mu      sync.Mutex
+	line    []byte // 未读完的行，只保留响应头名称所需的部分
+	started bool   // 已读取状态行
+	info    bool   // 当前为 1xx 响应
+	done    bool
+	names   []string
+}
+
+func (h *headerOrder) write(b []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(b) > 0 && !h.done {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			h.append(b)
+			return
+		}
+		h.append(b[:i])
+		b = b[i+1:]
+		h.endLine(bytes.TrimSuffix(h.line, []byte("\r")))
+		h.line = h.line[:0]
+	}
+}
+
+// append 追加到当前行，超长的部分不再记录
+func (h *headerOrder) append(b []byte) {
+	if n := 1024 - len(h.line); n < len(b) {
+		b = b[:max(n, 0)]
+	}
+	h.line = append(h.line, b...)
+}
+
+func (h *headerOrder) endLine(line []byte) {
+	switch {
+	case !h.started:
+		h.started = true
+		_, code, _ := bytes.Cut(line, []byte(" "))
+		h.info = len(code) > 0 && code[0] == '1'
+	case len(line) == 0:
+		if h.info {
+			// 1xx 响应之后还有最终响应
+			h.started, h.names = false, h.names[:0]
+		} else {
+			h.done = true
+		}
+	case len(h.names) < maxHeaderOrder:
+		if name, _, ok := bytes.Cut(line, []byte(":")); ok && len(name) > 0 {
+			h.names = append(h.names, string(name))
+		}
+	}
+}
+
+// order 获取响应头名称的接收顺序，未读完响应头时返回 nil
+func (h *headerOrder) order() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.done {
+		return nil
+	}
+	return h.names
+}
+
+// traceConn 记录连接建立与每次交互的耗时
+//
+// fasthttp 在取出连接后会调用 RemoteAddr 并保存到 `fasthttp.Response` 中，
+// 借助这一点，每次调用 RemoteAddr 即视为一次新的交互，并通过返回的 traceAddr 与响应关联
+type traceConn struct {
+	net.Conn
+	timing   connTiming
+	tlsState *tls.ConnectionState
+	seq      int
+	cur      atomic.Pointer[connExchange]
+	capture  func() bool // 是否记录每次交互的明文
+}
+
+// tlsStateConn 已完成（或可以完成）TLS 握手的连接，如 *tls.Conn
+type tlsStateConn interface {
+	Handshake() error
+	ConnectionState() tls.ConnectionState
+}
+
+// traceTLSConn TLS 连接，保留 Handshake、ConnectionState 方法避免 fasthttp、net/http 重复握手
+type traceTLSConn struct {
+	*traceConn
+	tlsConn tlsStateConn
+}
+
+func (c *traceTLSConn) Handshake() error {
+	return c.tlsConn.Handshake()
+}
+
+func (c *traceTLSConn) ConnectionState() tls.ConnectionState {
+	return c.tlsConn.ConnectionState()
+}
+
+// traceAddr 携带交互信息的远端地址
+type traceAddr struct {
+	net.Addr
+	exchange *connExchange
+}
+
+func newTraceConn(conn net.Conn, timing connTiming, capture func() bool) net.Conn {
+	tc := &traceConn{Conn: conn, timing: timing, capture: capture}
+	if tlsConn, ok := conn.(tlsStateConn); ok {
+		state := tlsConn.ConnectionState()
+		tc.tlsState = &state
+		return &traceTLSConn{traceConn: tc, tlsConn: tlsConn}
+	}
+	return tc
+}
+
+// unwrapTraceConn 获取 newTraceConn 创建的连接，其他连接返回 nil
+func unwrapTraceConn(conn net.Conn) *traceConn {
+	switch c := conn.(type) {
+	case *traceConn:
+		return c
+	case *traceTLSConn:
+		return c.traceConn
+	}
+	return nil
+}
+
+func (c *traceConn) RemoteAddr() net.Addr {
+	return &traceAddr{Addr: c.Conn.RemoteAddr(), exchange: c.begin()}
+}
+
+// begin 开始一次新的交互
+func (c *traceConn) begin() *connExchange {
+	ex := &connExchange{conn: c, seq: c.seq}
+	if prev := c.cur.Load(); prev != nil && !prev.lastRead.IsZero() {
+		ex.idle = time.Since(prev.lastRead)
+	}
+	if c.capture != nil && c.capture() {
+		ex.wire = &wireBuffer{}
+	}
+	c.seq++
+	c.cur.Store(ex)
+	return ex
+}
+
+// end 结束当前交互，之后的读写不再记录，用于连接升级（如 WebSocket）之后
+func (c *traceConn) end() {
+	c.cur.Store(nil)
+}
+
+func (c *traceConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if ex := c.cur.Load(); ex != nil {
+		ex.writeDone = time.Now()
+		if ex.wire != nil {
+			ex.wire.write(b[:n])
+		}
+	}
+	return n, err
+}
+
+func (c *traceConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if ex := c.cur.Load(); ex != nil && n > 0 {
+		now := time.Now()
+		if ex.firstByte.IsZero() {
+			ex.firstByte = now
+		}
+		ex.lastRead = now
+		if ex.wire != nil {
+			ex.wire.write(b[:n])
+		}
+		ex.header.write(b[:n])
+	}
+	return n, err
+}
+
+// newTiming 根据请求的起止时间以及响应所关联的连接生成耗时统计
+func newTiming(resp *fasthttp.Response, start, end time.Time) *Timing {
+	timing := &Timing{Total: end.Sub(start)}
+	if addr := resp.LocalAddr(); addr != nil {
+		timing.LocalAddr = addr.String()
+	}
+
+	ta, ok := resp.RemoteAddr().(*traceAddr)
+	if !ok {
+		if addr := resp.RemoteAddr(); addr != nil {
+			timing.RemoteAddr = addr.String()
+		}
+		return timing
+	}
+	timing.RemoteAddr = ta.String()
+
+	ex := ta.exchange
+	timing.tlsState = ex.conn.tlsState
+	timing.wire = ex.wire.bytes()
+	timing.ConnReused = ex.seq > 0
+	if timing.ConnReused {
+		timing.ConnIdleTime = ex.idle
+	} else {
+		timing.DNSLookup = ex.conn.timing.dnsLookup
+		timing.TCPConnect = ex.conn.timing.tcpConnect
+		timing.TLSHandshake = ex.conn.timing.tlsHandshake
+	}
+
+	if !ex.firstByte.IsZero() {
+		timing.TimeToFirstByte = ex.firstByte.Sub(start)
+		timing.BodyRead = end.Sub(ex.firstByte)
+		if !ex.writeDone.IsZero() {
+			timing.ServerProcessing = ex.firstByte.Sub(ex.writeDone)
+		}
+	}
+	return timing
+}
@@ -0,0 +1,154 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTiming(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, server.URL+"/", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timing := resp.Timing()
+	if timing == nil || timing.ConnReused || timing.TLSHandshake <= 0 || timing.TimeToFirstByte <= 0 {
+		t.Fatalf("unexpected timing of new connection: %v", timing)
+	}
+	t.Log(timing)
+
+	resp, err = client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timing = resp.Timing()
+	if !timing.ConnReused || timing.TLSHandshake != 0 || timing.Total < timing.TimeToFirstByte {
+		t.Fatalf("unexpected timing of reused connection: %v", timing)
+	}
+	t.Log(timing)
+
+	resp, err = client.R().AllowRedirect().AllowSaveResponseHistory().Get(server.URL + "/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, response := range resp.ResponseHistory() {
+		if response.Timing() == nil {
+			t.Fatalf("missing timing of %d response", response.Status())
+		}
+		t.Log(response.Status(), response.Timing())
+	}
+}
+
+func TestWireDump(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello " + r.URL.Query().Get("n")))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.R().Get(server.URL + "/?n=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.WireDump() != nil {
+		t.Fatal("wire dump should be disabled by default")
+	}
+
+	var buf bytes.Buffer
+	client.SetWireDumpWriter(&buf)
+	var dumps []string
+	for _, n := range []string{"1", "2"} {
+		resp, err = client.R().SetHeader("X-Seq", n).Get(server.URL + "/?n=" + n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dump := string(resp.WireDump())
+		// 复用的 TLS 连接上只包含本次请求的明文
+		if !strings.HasPrefix(dump, "GET /?n="+n+" HTTP/1.1\r\n") || !strings.Contains(dump, "\r\nX-Seq: "+n+"\r\n") ||
+			!strings.Contains(dump, "\r\n\r\nHTTP/1.1 200 OK\r\n") || !strings.HasSuffix(dump, "\r\n\r\nhello "+n) {
+			t.Fatalf("unexpected wire dump:\n%s", dump)
+		}
+		dumps = append(dumps, dump)
+	}
+	if buf.String() != strings.Join(dumps, "") {
+		t.Fatalf("unexpected writer output:\n%s", buf.String())
+	}
+
+	client.SetWireDumpWriter(nil).SetWireDump(false)
+	if resp, err = client.R().Get(server.URL + "/"); err != nil || resp.WireDump() != nil {
+		t.Fatalf("wire dump should be disabled: %v", err)
+	}
+}
+
+func TestWireDumpTransports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: hello\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient().SetTransport(NewHttpTransport()).SetWireDumpWriter(&buf)
+	for i := 0; i < 2; i++ {
+		resp, err := client.R().Get(server.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dump := string(resp.WireDump()); !strings.HasPrefix(dump, "GET / HTTP/1.1\r\n") || !strings.HasSuffix(dump, "\r\n\r\nhello") {
+			t.Fatalf("unexpected wire dump:\n%s", dump)
+		}
+	}
+
+	buf.Reset()
+	for event, err := range client.R().Stream(context.Background(), server.URL+"/events", nil) {
+		if err != nil || event.Data != "hello" {
+			t.Fatalf("unexpected event: %v, %v", event, err)
+		}
+		break
+	}
+	if dump := buf.String(); !strings.HasPrefix(dump, "GET /events HTTP/1.1\r\n") || !strings.Contains(dump, "data: hello\n\n") {
+		t.Fatalf("unexpected stream wire dump:\n%s", dump)
+	}
+
+	wsServer := newWebSocketServer(t, nil)
+	ws, resp, err := NewClient().SetWireDump(true).R().WebSocket(context.Background(), wsServer.URL+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if dump := string(resp.WireDump()); !strings.HasPrefix(dump, "GET /ws HTTP/1.1\r\n") ||
+		!strings.Contains(dump, "\r\n\r\nHTTP/1.1 101 Switching Protocols\r\n") {
+		t.Fatalf("unexpected websocket wire dump:\n%s", dump)
+	}
+}
+
+func TestWireBuffer(t *testing.T) {
+	w := &wireBuffer{}
+	w.write(bytes.Repeat([]byte("a"), maxWireSize-1))
+	w.write([]byte("bc"))
+	w.write([]byte("d"))
+	if wire := w.bytes(); len(wire) != maxWireSize+len("...(truncated)") || !bytes.HasSuffix(wire, []byte("b...(truncated)")) {
+		t.Fatalf("unexpected wire size %d", len(wire))
+	}
+	if (*wireBuffer)(nil).bytes() != nil {
+		t.Fatal("nil buffer should return nil")
+	}
+}
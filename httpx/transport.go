@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"github.com/valyala/fasthttp"
+	"time"
+)
+
+// Transport 请求传输层，`Client` 通过 Transport 发送请求，默认基于 fasthttp 实现（仅支持 HTTP/1.1），
+// 需要 HTTP/2 时可以使用基于 net/http 的 `HttpTransport`
+type Transport interface {
+	// RoundTrip 使用 cli 的配置发送请求并将响应写入 resp，返回本次请求的各阶段耗时（允许为 nil）
+	RoundTrip(cli *Client, req *fasthttp.Request, resp *fasthttp.Response) (*Timing, error)
+}
+
+// fastHttpTransport 基于 `fasthttp.Client` 的默认传输层
+type fastHttpTransport struct{}
+
+func (fastHttpTransport) RoundTrip(cli *Client, req *fasthttp.Request, resp *fasthttp.Response) (*Timing, error) {
+	cli.preCheck()
+	start := time.Now()
+	if err := cli.fastClient.Do(req, resp); err != nil {
+		return nil, err
+	}
+	timing := newTiming(resp, start, time.Now())
+	if timing.wire != nil {
+		cli.writeWireDump(timing.wire)
+	}
+	return timing, nil
+}
+
+// DefaultTransport 默认的传输层
+var DefaultTransport Transport = fastHttpTransport{}
@@ -0,0 +1,310 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/valyala/fasthttp"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MessageType WebSocket 消息类型
+type MessageType int
+
+const (
+	MessageText   MessageType = websocket.TextMessage   // 文本消息
+	MessageBinary MessageType = websocket.BinaryMessage // 二进制消息
+	MessageClose  MessageType = websocket.CloseMessage  // 关闭帧
+	MessagePing   MessageType = websocket.PingMessage   // ping 帧
+	MessagePong   MessageType = websocket.PongMessage   // pong 帧
+)
+
+// controlWriteTimeout 发送控制帧的超时时间
+const controlWriteTimeout = 5 * time.Second
+
+// WebSocketOptions WebSocket 连接配置
+type WebSocketOptions struct {
+	Subprotocols      []string      // 请求的子协议，通过 Sec-WebSocket-Protocol 发送
+	EnableCompression bool          // 协商 permessage-deflate 压缩
+	HandshakeTimeout  time.Duration // 握手超时时间，默认为 Client 的 ReadTimeout + WriteTimeout
+	ReadLimit         int64         // 单个消息的最大字节数，默认为 Client 的 MaxResponseBodySize
+}
+
+// WebSocket WebSocket 连接，读取与写入可以分别在不同的 goroutine 中进行
+type WebSocket struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// WebSocket 使用请求的请求头、Cookie、认证信息建立 WebSocket 连接，连接通过 Client 的 Dial（包括代理）与 TLSConfig 建立，
+// 连接地址与 SNI 设置同样生效。url 支持 ws、wss 以及 http、https，握手收到响应时返回握手响应（包括握手失败），
+// ctx 取消时关闭连接
+func (r *Request) WebSocket(ctx context.Context, url string, opts *WebSocketOptions) (*WebSocket, *Response, error) {
+	if opts == nil {
+		opts = &WebSocketOptions{}
+	}
+
+	r.clock.Lock()
+	prepared := r.clone()
+	r.clock.Unlock()
+	defer r.syncPrepared(prepared)
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	if err := prepared.preCheck(url, MethodGet, req); err != nil {
+		return nil, nil, fmt.Errorf("preCheck err: %v", err)
+	}
+	wsURL, isTLS, err := webSocketURL(prepared.url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 解析连接地址与 SNI，握手请求的 Host 保持为 URL 中的主机
+	prepared.route().apply(req)
+	host := string(req.URI().Host())
+	target, sni, connect, routed := parseRouteAddr(host)
+	if routed {
+		host = string(req.Header.Host())
+	}
+
+	cli := prepared.client
+	cli.clock.Lock()
+	handshakeTimeout := cli.ReadTimeout + cli.WriteTimeout
+	readLimit := int64(cli.MaxResponseBodySize)
+	noDefaultUserAgent := cli.NoDefaultUserAgentHeader
+	cli.clock.Unlock()
+	if opts.HandshakeTimeout > 0 {
+		handshakeTimeout = opts.HandshakeTimeout
+	}
+	if opts.ReadLimit > 0 {
+		readLimit = opts.ReadLimit
+	}
+
+	httpReq, err := toHttpRequest(ctx, req, host, noDefaultUserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := webSocketHeader(httpReq.Header)
+	header["Host"] = []string{host}
+
+	// 握手的交互，用于记录握手的明文
+	var handshake *connExchange
+	dial := func(addr string, isTLS bool) (net.Conn, error) {
+		hostPort := addr
+		if routed {
+			hostPort, addr = target, connect
+		}
+		// 只协商 HTTP/1.1，避免服务端选择 HTTP/2 导致无法升级，使用代理时通过隧道连接
+		conn, _, err := cli.dialConn(hostPort, addr, isTLS, true, sni, []string{"http/1.1"})
+		if tc := unwrapTraceConn(conn); tc != nil {
+			handshake = tc.begin()
+		}
+		return conn, err
+	}
+	dialer := &websocket.Dialer{
+		NetDialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			return dial(addr, false)
+		},
+		HandshakeTimeout:  handshakeTimeout,
+		Subprotocols:      opts.Subprotocols,
+		EnableCompression: opts.EnableCompression,
+	}
+	if isTLS {
+		dialer.NetDialTLSContext = func(_ context.Context, _, addr string) (net.Conn, error) {
+			return dial(addr, true)
+		}
+	}
+
+	conn, httpResp, err := dialer.DialContext(ctx, wsURL, header)
+	ex := &exchange{}
+	var order []string
+	if handshake != nil {
+		handshake.conn.end()
+		order = handshake.header.order()
+		if wire := handshake.wire.bytes(); wire != nil {
+			ex.timing = &Timing{wire: wire}
+			cli.writeWireDump(wire)
+		}
+	}
+	var resp *Response
+	if httpResp != nil {
+		fastResp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(fastResp)
+		if convErr := fromHttpResponse(httpResp, order, fastResp, false, 0); convErr == nil {
+			resp = prepared.postCheck(fastResp, ex, prepared.url)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return nil, resp, fmt.Errorf("websocket handshake failed with status %d", resp.Status())
+		}
+		return nil, resp, fmt.Errorf("websocket dial %s err: %w", wsURL, err)
+	}
+
+	if readLimit > 0 {
+		conn.SetReadLimit(readLimit)
+	}
+	ws := &WebSocket{conn: conn, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = ws.Close()
+		case <-ws.done:
+		}
+	}()
+	return ws, resp, nil
+}
+
+// webSocketURL 将请求地址转换为 WebSocket 地址
+func webSocketURL(url string) (string, bool, error) {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok {
+		return "", false, fmt.Errorf("invalid websocket url: %s", url)
+	}
+	switch strings.ToLower(scheme) {
+	case "ws", "http":
+		return "ws://" + rest, false, nil
+	case "wss", "https":
+		return "wss://" + rest, true, nil
+	}
+	return "", false, fmt.Errorf("unsupported websocket scheme: %s", scheme)
+}
+
+// webSocketHeader 移除由 WebSocket 握手自动设置的请求头
+func webSocketHeader(header http.Header) http.Header {
+	for key := range header {
+		switch strings.ToLower(key) {
+		case "upgrade", "connection", "sec-websocket-key", "sec-websocket-version", "sec-websocket-extensions":
+			delete(header, key)
+		case "sec-websocket-protocol":
+			// 与 Subprotocols 一致，使用规范的名称
+			values := header[key]
+			delete(header, key)
+			header["Sec-Websocket-Protocol"] = values
+		}
+	}
+	return header
+}
+
+// ReadMessage 读取一个文本或二进制消息，ping、pong 帧通过 OnPing、OnPong 处理，
+// 收到关闭帧或连接关闭时返回错误，可以通过 `IsCloseError` 判断
+func (ws *WebSocket) ReadMessage() (MessageType, []byte, error) {
+	messageType, data, err := ws.conn.ReadMessage()
+	return MessageType(messageType), data, err
+}
+
+// WriteMessage 发送消息，支持所有消息类型
+func (ws *WebSocket) WriteMessage(messageType MessageType, data []byte) error {
+	switch messageType {
+	case MessageClose, MessagePing, MessagePong:
+		// 控制帧可以与数据帧并发发送
+		return ws.conn.WriteControl(int(messageType), data, time.Now().Add(controlWriteTimeout))
+	}
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return ws.conn.WriteMessage(int(messageType), data)
+}
+
+// WriteText 发送文本消息
+func (ws *WebSocket) WriteText(text string) error {
+	return ws.WriteMessage(MessageText, []byte(text))
+}
+
+// WriteBinary 发送二进制消息
+func (ws *WebSocket) WriteBinary(data []byte) error {
+	return ws.WriteMessage(MessageBinary, data)
+}
+
+// Ping 发送 ping 帧
+func (ws *WebSocket) Ping(data []byte) error {
+	return ws.WriteMessage(MessagePing, data)
+}
+
+// Pong 发送 pong 帧
+func (ws *WebSocket) Pong(data []byte) error {
+	return ws.WriteMessage(MessagePong, data)
+}
+
+// OnPing 设置收到 ping 帧时的回调，回调之后仍会自动回复 pong 帧，回调在 ReadMessage 中执行
+func (ws *WebSocket) OnPing(f func(data []byte)) *WebSocket {
+	ws.conn.SetPingHandler(func(appData string) error {
+		f([]byte(appData))
+		err := ws.Pong([]byte(appData))
+		var netErr net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || errors.As(err, &netErr) && netErr.Timeout() {
+			return nil
+		}
+		return err
+	})
+	return ws
+}
+
+// OnPong 设置收到 pong 帧时的回调，回调在 ReadMessage 中执行
+func (ws *WebSocket) OnPong(f func(data []byte)) *WebSocket {
+	ws.conn.SetPongHandler(func(appData string) error {
+		f([]byte(appData))
+		return nil
+	})
+	return ws
+}
+
+// Subprotocol 获取服务端选择的子协议
+func (ws *WebSocket) Subprotocol() string {
+	return ws.conn.Subprotocol()
+}
+
+// SetCompression 设置发送消息时是否压缩，仅在协商了 permessage-deflate 时生效，默认压缩
+func (ws *WebSocket) SetCompression(enable bool) *WebSocket {
+	ws.conn.EnableWriteCompression(enable)
+	return ws
+}
+
+// LocalAddr 获取本地地址
+func (ws *WebSocket) LocalAddr() net.Addr {
+	return ws.conn.LocalAddr()
+}
+
+// RemoteAddr 获取远端地址
+func (ws *WebSocket) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// Close 发送正常关闭帧并关闭连接，可以重复调用
+func (ws *WebSocket) Close() error {
+	return ws.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+// CloseWithCode 使用指定的状态码与原因发送关闭帧并关闭连接，可以重复调用
+func (ws *WebSocket) CloseWithCode(code int, reason string) error {
+	ws.closeOnce.Do(func() {
+		close(ws.done)
+		_ = ws.WriteMessage(MessageClose, websocket.FormatCloseMessage(code, reason))
+		ws.closeErr = ws.conn.Close()
+	})
+	return ws.closeErr
+}
+
+// IsCloseError 判断错误是否为收到的关闭帧，codes 为空时任意状态码均返回 true
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package httpx
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newWebSocketServer 启动 WebSocket 回显服务，收到 ping 消息时发送 ping 帧
+func newWebSocketServer(t *testing.T, handshake func(r *http.Request)) *httptest.Server {
+	upgrader := websocket.Upgrader{EnableCompression: true, Subprotocols: []string{"chat"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handshake != nil {
+			handshake(r)
+		}
+		if r.URL.Path == "/forbidden" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, http.Header{"X-Server": {"ws"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetPongHandler(func(data string) error {
+			return conn.WriteMessage(websocket.TextMessage, []byte("pong:"+data))
+		})
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "ping" {
+				_ = conn.WriteControl(websocket.PingMessage, []byte("server"), time.Now().Add(time.Second))
+			}
+			if err = conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebSocket(t *testing.T) {
+	var handshake *http.Request
+	server := newWebSocketServer(t, func(r *http.Request) {
+		handshake = r
+	})
+
+	ws, resp, err := NewClient().R().
+		SetHeader("X-Token", "abc").
+		SetCookie("session", "1").
+		SetQueryParam("q", "1").
+		WebSocket(context.Background(), server.URL+"/ws", &WebSocketOptions{
+			Subprotocols:      []string{"chat"},
+			EnableCompression: true,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if resp.Status() != http.StatusSwitchingProtocols || resp.Header()["X-Server"] != "ws" || ws.Subprotocol() != "chat" {
+		t.Fatalf("unexpected handshake response: %s", resp.String())
+	}
+	if cookie, err := handshake.Cookie("session"); err != nil || cookie.Value != "1" ||
+		handshake.Header.Get("X-Token") != "abc" || handshake.URL.Query().Get("q") != "1" {
+		t.Fatalf("request settings not sent: %v %s", handshake.Header, handshake.URL)
+	}
+	if !strings.Contains(handshake.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate") {
+		t.Fatalf("compression not negotiated: %v", handshake.Header)
+	}
+
+	if err = ws.WriteText("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if messageType, data, err := ws.ReadMessage(); err != nil || messageType != MessageText || string(data) != "hello" {
+		t.Fatalf("unexpected message: %d %q %v", messageType, data, err)
+	}
+	if err = ws.WriteBinary([]byte{0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if messageType, data, err := ws.ReadMessage(); err != nil || messageType != MessageBinary || len(data) != 3 {
+		t.Fatalf("unexpected message: %d %q %v", messageType, data, err)
+	}
+
+	// 服务端收到 pong 帧后回复文本消息
+	if err = ws.Pong([]byte("client")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := ws.ReadMessage(); err != nil || string(data) != "pong:client" {
+		t.Fatalf("unexpected message: %q %v", data, err)
+	}
+
+	// 收到 ping 帧时回调并自动回复 pong 帧
+	pings := make(chan string, 1)
+	ws.OnPing(func(data []byte) {
+		pings <- string(data)
+	})
+	if err = ws.WriteText("ping"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"ping", "pong:server"} {
+		if _, data, err := ws.ReadMessage(); err != nil || string(data) != expected {
+			t.Fatalf("unexpected message: %q %v", data, err)
+		}
+	}
+	if data := <-pings; data != "server" {
+		t.Fatalf("unexpected ping: %s", data)
+	}
+}
+
+func TestWebSocketContextClose(t *testing.T) {
+	server := newWebSocketServer(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ws, _, err := NewClient().R().WebSocket(ctx, strings.Replace(server.URL, "http://", "ws://", 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := ws.ReadMessage()
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Fatal("expected error after context canceled")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("connection not closed after context canceled")
+	}
+}
+
+func TestWebSocketHandshakeFailed(t *testing.T) {
+	server := newWebSocketServer(t, nil)
+
+	_, resp, err := NewClient().R().WebSocket(context.Background(), server.URL+"/forbidden", nil)
+	if err == nil || resp == nil || resp.Status() != http.StatusForbidden {
+		t.Fatalf("expected handshake error with response, got %v", err)
+	}
+}
+
+func TestWebSocketTLSConnectAddress(t *testing.T) {
+	var host string
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+	}))
+	defer server.Close()
+
+	_, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "https://"), ":")
+	ws, _, err := NewClient().R().
+		SetConnectAddress(server.Listener.Addr().String()).
+		WebSocket(context.Background(), "wss://example.com:"+port+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if _, data, err := ws.ReadMessage(); err != nil || string(data) != "hi" || host != "example.com:"+port {
+		t.Fatalf("unexpected message: %q %v, host %s", data, err, host)
+	}
+}
@@ -20,6 +20,26 @@ func IPsFromCIDR(cidr string) ([]string, error) {
 	return mapcidr.IPAddresses(cidr)
 }
 
+// CIDRContains 判断 IP 地址是否在 CIDR 范围内，支持 IPv4 与 IPv6
+// @example: CIDRContains("192.168.1.0/24", "192.168.1.1")
+func CIDRContains(cidr, ip string) (bool, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, fmt.Errorf("invalid cidr: %s", cidr)
+	}
+	return Contains(ipNet, ip)
+}
+
+// Contains 判断 IP 地址是否在已解析的网段内，需要多次匹配同一网段时可以避免重复解析 CIDR
+// @example: _, ipNet, _ := net.ParseCIDR("192.168.1.0/24"); Contains(ipNet, "192.168.1.1")
+func Contains(ipNet *net.IPNet, ip string) (bool, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false, fmt.Errorf("invalid ip: %s", ip)
+	}
+	return ipNet.Contains(addr), nil
+}
+
 // CIDRFromIps 通过 IP_V4 地址生成 CIDR
 // return: 返回一个 map，其中 key 是对应的 CIDR，value 是此 CIDR 包含的 IP 个数
 func CIDRFromIps(ips []string) (map[string]int, error) {
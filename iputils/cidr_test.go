@@ -2,6 +2,7 @@ package iputils
 
 import (
 	"fmt"
+	"net"
 	"testing"
 )
 
@@ -49,3 +50,41 @@ func TestCIDRFromIps(t *testing.T) {
 		t.Logf("%s: %d\n", cidr, count)
 	}
 }
+
+func TestCIDRContains(t *testing.T) {
+	tests := []struct {
+		cidr, ip string
+		want     bool
+	}{
+		{"192.168.1.0/24", "192.168.1.100", true},
+		{"192.168.1.0/24", "192.168.2.1", false},
+		{"10.0.0.0/8", "10.255.0.1", true},
+		{"fd00::/8", "fd12::1", true},
+		{"fd00::/8", "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got, err := CIDRContains(tt.cidr, tt.ip); err != nil || got != tt.want {
+			t.Fatalf("CIDRContains(%s, %s) = %v, %v", tt.cidr, tt.ip, got, err)
+		}
+	}
+
+	if _, err := CIDRContains("192.168.1.0", "192.168.1.1"); err == nil {
+		t.Fatal("expected invalid cidr error")
+	}
+	if _, err := CIDRContains("192.168.1.0/24", "example.com"); err == nil {
+		t.Fatal("expected invalid ip error")
+	}
+}
+
+func TestContains(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("fd00::/8")
+	if ok, err := Contains(ipNet, "fd12::1"); err != nil || !ok {
+		t.Fatalf("Contains(fd00::/8, fd12::1) = %v, %v", ok, err)
+	}
+	if ok, err := Contains(ipNet, "10.0.0.1"); err != nil || ok {
+		t.Fatalf("Contains(fd00::/8, 10.0.0.1) = %v, %v", ok, err)
+	}
+	if _, err := Contains(ipNet, "example.com"); err == nil {
+		t.Fatal("expected invalid ip error")
+	}
+}
@@ -162,3 +162,8 @@ func Fatal() *zerolog.Event {
 func Panic() *zerolog.Event {
 	return log.Panic()
 }
+
+// Event 创建指定级别的日志事件，级别为 FatalLevel、PanicLevel 时不会退出程序
+func Event(level Level) *zerolog.Event {
+	return log.WithLevel(level.toZeroLogLevel())
+}